@@ -1,8 +1,11 @@
 package slim
 
 import (
+	"github.com/buexplain/go-slim/constant"
 	"github.com/buexplain/go-slim/tsmap"
 	"github.com/buexplain/go-slim/view"
+	"html/template"
+	"mime"
 	"net/http"
 	"strings"
 	"sync"
@@ -26,6 +29,8 @@ type App struct {
 	errorFunc      ErrorFunc
 	view           *view.View
 	sessionHandler SessionHandler
+	//允许通过POST请求覆盖的请求方法，为空则不开启
+	methodOverride []string
 }
 
 func New(debug bool) *App {
@@ -34,16 +39,9 @@ func New(debug bool) *App {
 	tmp.formMaxMemory = 10 << 20
 	tmp.bodyMaxBytes = 10 << 20
 	tmp.store = tsmap.New()
-	tmp.middleware = map[string][]Middleware{
-		http.MethodOptions: []Middleware{},
-		http.MethodHead:    []Middleware{},
-		http.MethodGet:     []Middleware{},
-		http.MethodPost:    []Middleware{},
-		http.MethodPatch:   []Middleware{},
-		http.MethodPut:     []Middleware{},
-		http.MethodDelete:  []Middleware{},
-		http.MethodTrace:   []Middleware{},
-		http.MethodConnect: []Middleware{},
+	tmp.middleware = map[string][]Middleware{}
+	for _, method := range methods {
+		tmp.middleware[method] = []Middleware{}
 	}
 	tmp.pool = &sync.Pool{
 		New: func() interface{} {
@@ -75,21 +73,11 @@ func (this *App) Store() *tsmap.TSMap {
 
 func (this *App) Use(m Middleware, methods ...string) *App {
 	if len(methods) == 0 || strings.ToUpper(methods[0]) == "ANY" {
-		methods = []string{
-			http.MethodOptions,
-			http.MethodHead,
-			http.MethodGet,
-			http.MethodPost,
-			http.MethodPatch,
-			http.MethodPut,
-			http.MethodDelete,
-			http.MethodTrace,
-			http.MethodConnect,
-		}
+		methods = Methods()
 	}
 	for _, v := range methods {
 		v = strings.ToUpper(v)
-		if _, ok := this.middleware[v]; !ok {
+		if !isMethod(v) {
 			panic("unknown http method")
 		}
 		this.middleware[v] = append(this.middleware[v], m)
@@ -110,13 +98,56 @@ func (this *App) SetErrorFunc(errorFunc ErrorFunc) {
 }
 
 func (this *App) SetView(view *view.View) {
+	if view != nil {
+		//注册根据路由名称生成url的模板函数，渲染时会替换为当前app的，同一个view可以被多个app共用
+		view.AddFunc("URL", this.mux.urlFunc)
+	}
 	this.view = view
 }
 
+//渲染模板时替换的模板函数
+func (this *App) viewFuncs() template.FuncMap {
+	return template.FuncMap{"URL": this.mux.urlFunc}
+}
+
 func (this *App) View() *view.View {
 	return this.view
 }
 
+//开启请求方法覆盖，methods是允许覆盖成的请求方法，不传则关闭
+//开启后，POST请求可以通过表单字段 _method 或者请求头 X-HTTP-Method-Override 指定真正的请求方法，请求头优先
+//覆盖发生在全局中间件与路由匹配之前，覆盖后 Request.Raw().Method 为新的请求方法
+//比如 SetMethodOverride(http.MethodPut, http.MethodPatch, http.MethodDelete)
+func (this *App) SetMethodOverride(methods ...string) {
+	this.methodOverride = make([]string, 0, len(methods))
+	for _, v := range methods {
+		v = strings.ToUpper(v)
+		if !isMethod(v) {
+			panic("unknown http method: " + v)
+		}
+		this.methodOverride = append(this.methodOverride, v)
+	}
+}
+
+//读取POST请求中要覆盖成的请求方法，不在允许的范围内则返回空字符串
+func (this *App) overrideMethod(ctx *Ctx) string {
+	method := ctx.r.r.Header.Get(constant.HeaderXHTTPMethodOverride)
+	if method == "" {
+		//只从表单中读取，避免解析其它类型的body
+		ct, _, _ := mime.ParseMediaType(ctx.r.r.Header.Get(constant.HeaderContentType))
+		if ct == constant.MIMEApplicationForm || ct == constant.MIMEMultipartForm {
+			method = ctx.r.Form("_method")
+		}
+	}
+	method = strings.ToUpper(strings.TrimSpace(method))
+	for _, v := range this.methodOverride {
+		if v == method {
+			return method
+		}
+	}
+	return ""
+}
+
 func (this *App) SetSessionHandler(sessionHandler SessionHandler) {
 	this.sessionHandler = sessionHandler
 }
@@ -129,17 +160,35 @@ func (this *App) Server(addr string) *http.Server {
 	return &http.Server{Addr: addr, Handler: this}
 }
 
+//校验并冻结路由，校验不通过时返回发现的全部问题
+//Run与RunTLS会自动调用，直接使用Server启动服务时需要手动调用
+func (this *App) Freeze() error {
+	return this.mux.Freeze()
+}
+
 func (this *App) Run(addr string) error {
+	if err := this.Freeze(); err != nil {
+		return err
+	}
 	return this.Server(addr).ListenAndServe()
 }
 
 func (this *App) RunTLS(addr, certFile, keyFile string) error {
+	if err := this.Freeze(); err != nil {
+		return err
+	}
 	return this.Server(addr).ListenAndServeTLS(certFile, keyFile)
 }
 
 func (this *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := this.pool.Get().(*Ctx)
 	ctx.reset(w, r)
+	if len(this.methodOverride) > 0 && r.Method == http.MethodPost {
+		if method := this.overrideMethod(ctx); method != "" {
+			r.Method = method
+			ctx.middleware = this.middleware[method]
+		}
+	}
 	defer func(app *App, context *Ctx) {
 		if !context.w.send() {
 			_ = context.w.send()
@@ -150,7 +199,7 @@ func (this *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer func(app *App, context *Ctx) {
 		if a := recover(); a != nil {
 			context.w.buffer.Reset()
-			app.recoverFunc(context, a)
+			context.recoverFunc()(context, a)
 		}
 	}(this, ctx)
 	ctx.SetPath(r.URL.Path)
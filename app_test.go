@@ -0,0 +1,77 @@
+package slim
+
+import (
+	"github.com/buexplain/go-slim/constant"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//测试请求方法覆盖：请求头优先于表单、只允许覆盖成指定的请求方法、只覆盖POST请求
+func TestMethodOverride(t *testing.T) {
+	app := New(true)
+	app.SetMethodOverride(http.MethodPut, "delete")
+	app.Use(func(ctx *Ctx, w *Response, r *Request) {
+		w.Header().Set("X-Middleware", r.Raw().Method)
+		ctx.Next()
+	}, http.MethodPut)
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch} {
+		method := method
+		app.Mux().Add("/item", func(ctx *Ctx, w *Response, r *Request) error {
+			return w.Plain(http.StatusOK, method+" "+r.Raw().Method)
+		}, method)
+	}
+	for _, v := range []struct {
+		method string
+		header string
+		form   string
+		result string
+	}{
+		{http.MethodPost, "", "", "POST POST"},
+		{http.MethodPost, "", "_method=put", "PUT PUT"},
+		{http.MethodPost, "DELETE", "", "DELETE DELETE"},
+		//请求头优先于表单
+		{http.MethodPost, "delete", "_method=PUT", "DELETE DELETE"},
+		//不在允许的范围内
+		{http.MethodPost, "PATCH", "", "POST POST"},
+		{http.MethodPost, "", "_method=PATCH", "POST POST"},
+		{http.MethodPost, "GET", "", "POST POST"},
+		//只覆盖POST请求
+		{http.MethodGet, "PUT", "", "GET GET"},
+		{http.MethodPatch, "PUT", "", "PATCH PATCH"},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(v.method, "/item", strings.NewReader(v.form))
+		if v.form != "" {
+			req.Header.Set(constant.HeaderContentType, constant.MIMEApplicationForm)
+		}
+		if v.header != "" {
+			req.Header.Set(constant.HeaderXHTTPMethodOverride, v.header)
+		}
+		app.ServeHTTP(rec, req)
+		if rec.Body.String() != v.result {
+			t.Fatal("TestMethodOverride fatal", v.method, v.header, v.form, rec.Body.String())
+		}
+		//覆盖发生在全局中间件之前
+		if strings.HasPrefix(v.result, "PUT") != (rec.Header().Get("X-Middleware") == http.MethodPut) {
+			t.Fatal("TestMethodOverride middleware fatal", v.method, v.header, v.form, rec.Header())
+		}
+	}
+
+	//非表单的body不会被读取
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/item", strings.NewReader(`{"_method":"PUT"}`))
+	req.Header.Set(constant.HeaderContentType, constant.MIMEApplicationJSON)
+	app.ServeHTTP(rec, req)
+	if rec.Body.String() != "POST POST" {
+		t.Fatal("TestMethodOverride json fatal", rec.Body.String())
+	}
+
+	//关闭后不再覆盖
+	app.SetMethodOverride()
+	rec = serveHeader(app, http.MethodPost, "/item", http.Header{constant.HeaderXHTTPMethodOverride: {"PUT"}})
+	if rec.Body.String() != "POST POST" {
+		t.Fatal("TestMethodOverride disable fatal", rec.Body.String())
+	}
+}
@@ -0,0 +1,150 @@
+package slim
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/buexplain/go-slim/constant"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//默认压缩的内容类型
+var CompressContentTypes = []string{
+	constant.MIMETextHTML,
+	constant.MIMETextPlain,
+	"text/css",
+	"text/javascript",
+	"text/xml",
+	constant.MIMEApplicationJSON,
+	constant.MIMEApplicationJavaScript,
+	constant.MIMEApplicationXML,
+	"image/svg+xml",
+}
+
+//响应压缩中间件，根据 Accept-Encoding 对响应缓冲中的内容进行gzip或者是deflate压缩
+//minLength 是压缩的最小字节数，contentTypes 是允许压缩的内容类型，支持 text/* 形式的通配，为空则使用 CompressContentTypes
+//已经设置了 Content-Encoding 的响应、流式响应、被劫持的连接不会被压缩
+func Compress(minLength int, contentTypes ...string) Middleware {
+	if len(contentTypes) == 0 {
+		contentTypes = CompressContentTypes
+	}
+	return func(ctx *Ctx, w *Response, r *Request) {
+		ctx.Next()
+		if w.Streaming() || w.Hijacked() {
+			return
+		}
+		switch w.statusCode {
+		case 0, http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+			return
+		}
+		header := w.Header()
+		if !compressible(header.Get(constant.HeaderContentType), contentTypes) {
+			return
+		}
+		if !headerHasToken(header, constant.HeaderVary, constant.HeaderAcceptEncoding) {
+			header.Add(constant.HeaderVary, constant.HeaderAcceptEncoding)
+		}
+		if header.Get(constant.HeaderContentEncoding) != "" || w.buffer.Len() < minLength {
+			return
+		}
+		encoding := acceptEncoding(r.Raw().Header.Get(constant.HeaderAcceptEncoding))
+		if encoding == "" {
+			return
+		}
+		buff := &bytes.Buffer{}
+		var zw io.WriteCloser
+		if encoding == "gzip" {
+			zw = gzip.NewWriter(buff)
+		} else {
+			zw = zlib.NewWriter(buff)
+		}
+		if _, err := zw.Write(w.buffer.Bytes()); err != nil {
+			return
+		}
+		if err := zw.Close(); err != nil {
+			return
+		}
+		//压缩后没有变小则不压缩
+		if buff.Len() >= w.buffer.Len() {
+			return
+		}
+		w.buffer.Reset()
+		_, _ = buff.WriteTo(w.buffer)
+		header.Set(constant.HeaderContentEncoding, encoding)
+		header.Del(constant.HeaderContentLength)
+		//压缩后的内容与原内容不是字节相同的，强校验的ETag转为弱校验
+		if etag := header.Get(constant.HeaderETag); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set(constant.HeaderETag, "W/"+etag)
+		}
+	}
+}
+
+//判断内容类型是否允许压缩
+func compressible(contentType string, contentTypes []string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, v := range contentTypes {
+		if strings.HasSuffix(v, "/*") {
+			if strings.HasPrefix(mediaType, v[:len(v)-1]) {
+				return true
+			}
+		} else if strings.EqualFold(mediaType, v) {
+			return true
+		}
+	}
+	return false
+}
+
+//根据 Accept-Encoding 选择压缩方式，权重相同时优先gzip，不支持则返回空字符串
+func acceptEncoding(accept string) string {
+	weights := map[string]float64{}
+	for _, v := range strings.Split(accept, ",") {
+		name, q := v, 1.0
+		if i := strings.IndexByte(v, ';'); i != -1 {
+			name = v[:i]
+			param := strings.TrimSpace(v[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				if f, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			weights[name] = q
+		}
+	}
+	var encoding string
+	var weight float64
+	for _, name := range []string{"gzip", "deflate"} {
+		q, ok := weights[name]
+		if !ok {
+			//未明确列出的压缩方式使用 * 的权重
+			q = weights["*"]
+		}
+		if q > weight {
+			encoding, weight = name, q
+		}
+	}
+	return encoding
+}
+
+//判断header中是否包含某个token，不区分大小写
+func headerHasToken(header http.Header, name string, token string) bool {
+	for _, v := range header[http.CanonicalHeaderKey(name)] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s == "*" || strings.EqualFold(s, token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package slim
+
+import (
+	"bufio"
+	"compress/gzip"
+	"github.com/buexplain/go-slim/constant"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//足够长且可以被压缩的内容
+var compressBody = strings.Repeat("hello slim ", 100)
+
+//发起一个带请求头的请求，返回响应
+func serveHeader(handler http.Handler, method string, target string, header http.Header) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+//新建一个使用压缩中间件的app，路由 / 由handler处理
+func newCompressApp(minLength int, handler Handler) *App {
+	app := New(true)
+	app.Use(Compress(minLength))
+	app.Mux().Get("/", handler)
+	return app
+}
+
+//测试 Accept-Encoding 的解析
+func TestAcceptEncoding(t *testing.T) {
+	for accept, encoding := range map[string]string{
+		"":                         "",
+		"identity":                 "",
+		"gzip":                     "gzip",
+		"deflate":                  "deflate",
+		"deflate, gzip":            "gzip",
+		"deflate, gzip;q=0.5":      "deflate",
+		"gzip;q=0, deflate":        "deflate",
+		"gzip;q=0":                 "",
+		"GZIP;q=0, deflate;q=0":    "",
+		"*":                        "gzip",
+		"*;q=0":                    "",
+		"gzip;q=0, *":              "deflate",
+		"deflate;q=0.5, *;q=0.8":   "gzip",
+		"br, gzip;q=0.1, identity": "gzip",
+	} {
+		if tmp := acceptEncoding(accept); tmp != encoding {
+			t.Fatal("TestAcceptEncoding fatal", accept, tmp)
+		}
+	}
+}
+
+//测试压缩以及 q=0 的压缩方式不会被使用
+func TestCompress(t *testing.T) {
+	app := newCompressApp(0, func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, compressBody)
+	})
+	rec := serveHeader(app, http.MethodGet, "/", http.Header{constant.HeaderAcceptEncoding: {"gzip"}})
+	if rec.Header().Get(constant.HeaderContentEncoding) != "gzip" || rec.Header().Get(constant.HeaderVary) != constant.HeaderAcceptEncoding {
+		t.Fatal("TestCompress fatal", rec.Header())
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadAll(zr); err != nil || string(b) != compressBody {
+		t.Fatal("TestCompress body fatal", err)
+	}
+
+	rec = serveHeader(app, http.MethodGet, "/", http.Header{constant.HeaderAcceptEncoding: {"gzip;q=0"}})
+	if rec.Header().Get(constant.HeaderContentEncoding) != "" || rec.Body.String() != compressBody {
+		t.Fatal("TestCompress q=0 fatal", rec.Header())
+	}
+	//没有压缩也要告知缓存按 Accept-Encoding 区分
+	if rec.Header().Get(constant.HeaderVary) != constant.HeaderAcceptEncoding {
+		t.Fatal("TestCompress q=0 vary fatal", rec.Header())
+	}
+	rec = serveHeader(app, http.MethodGet, "/", http.Header{constant.HeaderAcceptEncoding: {"gzip;q=0, deflate"}})
+	if rec.Header().Get(constant.HeaderContentEncoding) != "deflate" {
+		t.Fatal("TestCompress deflate fatal", rec.Header())
+	}
+}
+
+//测试内容长度小于 minLength 时不压缩
+func TestCompressMinLength(t *testing.T) {
+	minLength := len(compressBody)
+	header := http.Header{constant.HeaderAcceptEncoding: {"gzip"}}
+	app := newCompressApp(minLength, func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, compressBody[:len(compressBody)-1])
+	})
+	if rec := serveHeader(app, http.MethodGet, "/", header); rec.Header().Get(constant.HeaderContentEncoding) != "" {
+		t.Fatal("TestCompressMinLength fatal", rec.Header())
+	}
+	app = newCompressApp(minLength, func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, compressBody)
+	})
+	if rec := serveHeader(app, http.MethodGet, "/", header); rec.Header().Get(constant.HeaderContentEncoding) != "gzip" {
+		t.Fatal("TestCompressMinLength fatal", rec.Header())
+	}
+}
+
+//测试已经设置了 Content-Encoding 的内容不会被再次压缩
+func TestCompressEncoded(t *testing.T) {
+	app := newCompressApp(0, func(ctx *Ctx, w *Response, r *Request) error {
+		w.Header().Set(constant.HeaderContentEncoding, "br")
+		return w.Plain(http.StatusOK, compressBody)
+	})
+	rec := serveHeader(app, http.MethodGet, "/", http.Header{constant.HeaderAcceptEncoding: {"gzip"}})
+	if rec.Header().Get(constant.HeaderContentEncoding) != "br" || rec.Body.String() != compressBody {
+		t.Fatal("TestCompressEncoded fatal", rec.Header())
+	}
+}
+
+//可以被劫持的 httptest.ResponseRecorder
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (this *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return this.conn, bufio.NewReadWriter(bufio.NewReader(this.conn), bufio.NewWriter(this.conn)), nil
+}
+
+//测试流式响应与被劫持的连接不会被压缩
+func TestCompressStreamAndHijack(t *testing.T) {
+	header := http.Header{constant.HeaderAcceptEncoding: {"gzip"}}
+	app := newCompressApp(0, func(ctx *Ctx, w *Response, r *Request) error {
+		w.Header().Set(constant.HeaderContentType, constant.MIMETextPlainCharsetUTF8)
+		if err := w.Stream(http.StatusOK); err != nil {
+			return err
+		}
+		_, err := w.Write([]byte(compressBody))
+		return err
+	})
+	rec := serveHeader(app, http.MethodGet, "/", header)
+	if rec.Header().Get(constant.HeaderContentEncoding) != "" || rec.Body.String() != compressBody {
+		t.Fatal("TestCompressStreamAndHijack stream fatal", rec.Header())
+	}
+
+	app = newCompressApp(0, func(ctx *Ctx, w *Response, r *Request) error {
+		w.Header().Set(constant.HeaderContentType, constant.MIMETextPlainCharsetUTF8)
+		conn, _, err := w.Hijack()
+		if err != nil {
+			return err
+		}
+		_ = conn.Close()
+		//劫持之后写入的内容只会停留在响应缓冲中
+		_, err = w.Write([]byte(compressBody))
+		w.WriteHeader(http.StatusOK)
+		return err
+	})
+	server, client := net.Pipe()
+	defer client.Close()
+	hijack := &hijackRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header = header
+	app.ServeHTTP(hijack, req)
+	if hijack.Header().Get(constant.HeaderContentEncoding) != "" || hijack.Header().Get(constant.HeaderVary) != "" {
+		t.Fatal("TestCompressStreamAndHijack hijack fatal", hijack.Header())
+	}
+}
+
+//测试不会重复添加 Vary
+func TestCompressVary(t *testing.T) {
+	for _, vary := range []string{"Accept-Encoding", "accept-encoding", "Origin, Accept-Encoding", "*"} {
+		app := newCompressApp(0, func(ctx *Ctx, w *Response, r *Request) error {
+			w.Header().Set(constant.HeaderVary, vary)
+			return w.Plain(http.StatusOK, compressBody)
+		})
+		rec := serveHeader(app, http.MethodGet, "/", http.Header{constant.HeaderAcceptEncoding: {"gzip"}})
+		if tmp := rec.Header()[constant.HeaderVary]; len(tmp) != 1 || tmp[0] != vary {
+			t.Fatal("TestCompressVary fatal", vary, tmp)
+		}
+	}
+	app := newCompressApp(0, func(ctx *Ctx, w *Response, r *Request) error {
+		w.Header().Set(constant.HeaderVary, "Origin")
+		return w.Plain(http.StatusOK, compressBody)
+	})
+	rec := serveHeader(app, http.MethodGet, "/", http.Header{constant.HeaderAcceptEncoding: {"gzip"}})
+	if tmp := rec.Header()[constant.HeaderVary]; len(tmp) != 2 || tmp[1] != constant.HeaderAcceptEncoding {
+		t.Fatal("TestCompressVary fatal", tmp)
+	}
+}
+
+//测试压缩后强校验的ETag转为弱校验
+func TestCompressETag(t *testing.T) {
+	for etag, result := range map[string]string{`"abc"`: `W/"abc"`, `W/"abc"`: `W/"abc"`} {
+		app := newCompressApp(0, func(ctx *Ctx, w *Response, r *Request) error {
+			w.SetETag(etag)
+			return w.Plain(http.StatusOK, compressBody)
+		})
+		rec := serveHeader(app, http.MethodGet, "/", http.Header{constant.HeaderAcceptEncoding: {"gzip"}})
+		if tmp := rec.Header().Get(constant.HeaderETag); tmp != result {
+			t.Fatal("TestCompressETag fatal", etag, tmp)
+		}
+		//未压缩时保持强校验
+		rec = serveHeader(app, http.MethodGet, "/", nil)
+		if tmp := rec.Header().Get(constant.HeaderETag); tmp != etag {
+			t.Fatal("TestCompressETag identity fatal", etag, tmp)
+		}
+	}
+}
@@ -20,6 +20,7 @@ const (
 	MIMETextHTMLCharsetUTF8              = MIMETextHTML + "; " + CharsetUTF8
 	MIMETextPlain                        = "text/plain"
 	MIMETextPlainCharsetUTF8             = MIMETextPlain + "; " + CharsetUTF8
+	MIMETextEventStream                  = "text/event-stream"
 	MIMEMultipartForm                    = "multipart/form-data"
 	MIMEOctetStream                      = "application/octet-stream"
 )
@@ -33,9 +34,13 @@ const (
 	HeaderContentEncoding               = "Content-Encoding"
 	HeaderContentLength                 = "Content-Length"
 	HeaderContentType                   = "Content-Type"
+	HeaderETag                          = "ETag"
 	HeaderCookie                        = "Cookie"
 	HeaderSetCookie                     = "Set-Cookie"
 	HeaderIfModifiedSince               = "If-Modified-Since"
+	HeaderIfUnmodifiedSince             = "If-Unmodified-Since"
+	HeaderIfMatch                       = "If-Match"
+	HeaderIfNoneMatch                   = "If-None-Match"
 	HeaderLastModified                  = "Last-Modified"
 	HeaderLocation                      = "Location"
 	HeaderUpgrade                       = "Upgrade"
@@ -58,6 +63,8 @@ const (
 	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
 	HeaderP3P                           = "P3P"
 	HeaderCacheControl                  = "Cache-control"
+	HeaderLastEventID                   = "Last-Event-ID"
+	HeaderXAccelBuffering               = "X-Accel-Buffering"
 
 	// Security
 	HeaderStrictTransportSecurity = "Strict-Transport-Security"
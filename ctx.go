@@ -1,8 +1,11 @@
 package slim
 
 import (
+	"context"
 	"github.com/buexplain/go-slim/tsmap"
 	"net/http"
+	"path"
+	"time"
 )
 
 //请求上下文
@@ -25,6 +28,8 @@ type Ctx struct {
 	route *Route
 	//用于路由匹配的path
 	routeMatchPath string
+	//设置的原始path
+	rawPath string
 }
 
 //新建一个上下文
@@ -62,6 +67,7 @@ func (this *Ctx) release() {
 	this.nextJ = 0
 	this.route = nil
 	this.routeMatchPath = ""
+	this.rawPath = ""
 }
 
 //返回上下文存储容器
@@ -125,7 +131,23 @@ func (this *Ctx) Break() {
 
 //抛出一个错误
 func (this *Ctx) Throw(err error) {
-	this.app.errorFunc(this, err)
+	this.errorFunc()(this, err)
+}
+
+//返回当前请求的错误处理函数，命中的路由以及所属的路由组都没有设置则使用app的
+func (this *Ctx) errorFunc() ErrorFunc {
+	if this.route != nil && this.route.currErrorFunc != nil {
+		return this.route.currErrorFunc
+	}
+	return this.app.errorFunc
+}
+
+//返回当前请求的恐慌恢复函数，命中的路由以及所属的路由组都没有设置则使用app的
+func (this *Ctx) recoverFunc() RecoverFunc {
+	if this.route != nil && this.route.currRecoverFunc != nil {
+		return this.route.currRecoverFunc
+	}
+	return this.app.recoverFunc
 }
 
 //返回app
@@ -133,25 +155,63 @@ func (this *Ctx) App() *App {
 	return this.app
 }
 
-//设置用于路由匹配的path
-func (this *Ctx) SetPath(path string) {
-	l := len(path)
-	c := "/"
-	if l == 0 {
-		path = c
-	} else {
-		if path[0:1] != c {
-			path = c + path
-		}
-		l = len(path)
-		if l > 1 && path[l-1:] == c {
-			path = path[:l-1]
-		}
+//设置用于路由匹配的path，path会被规范化：补全开头的斜杠、移除末尾的斜杠、合并连续的斜杠、解析 . 与 .. 段
+func (this *Ctx) SetPath(p string) {
+	this.rawPath = p
+	if len(p) == 0 || p[0] != '/' {
+		p = "/" + p
 	}
-	this.routeMatchPath = path
+	this.routeMatchPath = path.Clean(p)
 }
 
 //获取用于路由匹配的path
 func (this *Ctx) Path() string {
 	return this.routeMatchPath
 }
+
+//返回当前请求的context.Context
+func (this *Ctx) Context() context.Context {
+	if this.r.r == nil {
+		return context.Background()
+	}
+	return this.r.r.Context()
+}
+
+//替换当前请求的context.Context，之后的中间件与处理函数通过 Ctx 或者 Request.Raw().Context() 获取的都是新的
+func (this *Ctx) SetContext(c context.Context) {
+	this.r.r = this.r.r.WithContext(c)
+}
+
+//给当前请求设置超时时间，超时后 Done 返回的通道会被关闭，传入了 Ctx 的数据库查询等调用会被取消
+//返回的函数用于提前释放相关资源，应该在处理结束后调用
+func (this *Ctx) WithTimeout(timeout time.Duration) context.CancelFunc {
+	c, cancel := context.WithTimeout(this.Context(), timeout)
+	this.SetContext(c)
+	return cancel
+}
+
+//实现context.Context，委托给当前请求的context.Context
+func (this *Ctx) Deadline() (deadline time.Time, ok bool) {
+	return this.Context().Deadline()
+}
+
+//实现context.Context，委托给当前请求的context.Context
+func (this *Ctx) Done() <-chan struct{} {
+	return this.Context().Done()
+}
+
+//实现context.Context，委托给当前请求的context.Context
+func (this *Ctx) Err() error {
+	return this.Context().Err()
+}
+
+//实现context.Context，key是字符串时优先从上下文存储容器中获取，获取不到再从当前请求的context.Context中获取
+//Ctx会被复用，不能在请求结束后继续使用Ctx或者是从Ctx派生的context.Context
+func (this *Ctx) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		if v := this.store.Get(k); v != nil {
+			return v
+		}
+	}
+	return this.Context().Value(key)
+}
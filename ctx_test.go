@@ -0,0 +1,77 @@
+package slim
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type testCtxKey struct{}
+
+//测试Ctx作为context.Context使用
+func TestCtxContext(t *testing.T) {
+	app := New(true)
+	app.Use(func(ctx *Ctx, w *Response, r *Request) {
+		ctx.Store().Set("user", "bob")
+		ctx.SetContext(context.WithValue(ctx.Context(), testCtxKey{}, "request"))
+		ctx.Next()
+	})
+	app.Mux().Get("/", func(ctx *Ctx, w *Response, r *Request) error {
+		var c context.Context = ctx
+		//上下文存储容器中的值
+		if c.Value("user") != "bob" {
+			t.Error("TestCtxContext store fatal", c.Value("user"))
+		}
+		//SetContext设置的值，通过Ctx与Request.Raw().Context()都可以获取
+		if c.Value(testCtxKey{}) != "request" || r.Raw().Context().Value(testCtxKey{}) != "request" {
+			t.Error("TestCtxContext value fatal")
+		}
+		if c.Value("none") != nil {
+			t.Error("TestCtxContext none fatal")
+		}
+		return w.Plain(http.StatusOK, "ok")
+	})
+	if rec := serve(app, http.MethodGet, "/"); rec.Body.String() != "ok" {
+		t.Fatal("TestCtxContext fatal", rec.Body.String())
+	}
+}
+
+//测试WithTimeout取消当前请求的context.Context
+func TestCtxWithTimeout(t *testing.T) {
+	app := New(true)
+	app.Mux().Get("/", func(ctx *Ctx, w *Response, r *Request) error {
+		cancel := ctx.WithTimeout(10 * time.Millisecond)
+		defer cancel()
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("TestCtxWithTimeout deadline fatal")
+		}
+		select {
+		case <-r.Raw().Context().Done():
+		case <-time.After(time.Second):
+			t.Error("TestCtxWithTimeout done fatal")
+		}
+		if ctx.Err() != context.DeadlineExceeded || r.Raw().Context().Err() != context.DeadlineExceeded {
+			t.Error("TestCtxWithTimeout err fatal", ctx.Err())
+		}
+		select {
+		case <-ctx.Done():
+		default:
+			t.Error("TestCtxWithTimeout ctx done fatal")
+		}
+		return w.Plain(http.StatusOK, "ok")
+	})
+	if rec := serve(app, http.MethodGet, "/"); rec.Body.String() != "ok" {
+		t.Fatal("TestCtxWithTimeout fatal", rec.Body.String())
+	}
+	//提前调用返回的函数同样会取消
+	app.Mux().Get("/cancel", func(ctx *Ctx, w *Response, r *Request) error {
+		cancel := ctx.WithTimeout(time.Hour)
+		cancel()
+		if r.Raw().Context().Err() != context.Canceled {
+			t.Error("TestCtxWithTimeout cancel fatal", r.Raw().Context().Err())
+		}
+		return nil
+	})
+	serve(app, http.MethodGet, "/cancel")
+}
@@ -0,0 +1,29 @@
+package slim
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"github.com/buexplain/go-slim/constant"
+	"net/http"
+)
+
+//ETag中间件，GET、HEAD请求成功响应后，对响应缓冲中的内容计算哈希作为ETag，并校验请求的前提条件，满足则响应304
+//weak为true则生成弱校验的ETag，处理函数通过 Response 的 SetETag 设置了ETag的，不再计算哈希
+//其它请求的前提条件需要在处理函数中修改资源之前，通过 Response 的 CheckPreconditions 校验
+func ETag(weak ...bool) Middleware {
+	isWeak := len(weak) > 0 && weak[0]
+	return func(ctx *Ctx, w *Response, r *Request) {
+		ctx.Next()
+		if w.Streaming() || w.Hijacked() || w.statusCode != http.StatusOK {
+			return
+		}
+		if method := r.Raw().Method; method != http.MethodGet && method != http.MethodHead {
+			return
+		}
+		if w.Header().Get(constant.HeaderETag) == "" {
+			sum := sha1.Sum(w.buffer.Bytes())
+			w.SetETag(hex.EncodeToString(sum[:]), isWeak)
+		}
+		w.CheckPreconditions()
+	}
+}
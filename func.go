@@ -34,28 +34,38 @@ func defaultRecoverFunc(ctx *Ctx, a interface{}) {
 
 //服务端错误处理
 func defaultServerErrorFunc(ctx *Ctx, markerErr *errors.MrKErr) {
+	if streamErrorFunc(ctx, markerErr) {
+		return
+	}
 	ctx.Response().Buffer().Reset()
 	isDebug := ctx.App().Debug()
 	isJSON := (!ctx.Request().AcceptText() || (ctx.Route() != nil && ctx.Route().HasLabel("json")))
+	//错误码是503时，比如请求超时，作为http状态码响应
+	jsonStatusCode := http.StatusOK
+	statusCode := http.StatusInternalServerError
+	if markerErr.Code() == http.StatusServiceUnavailable {
+		jsonStatusCode = http.StatusServiceUnavailable
+		statusCode = http.StatusServiceUnavailable
+	}
 	var responseErr error
 	if isJSON {
 		//返回json
 		if isDebug {
 			//返回具体错误
-			responseErr = ctx.Response().Error(markerErr.Code(), markerErr.Error(), http.StatusOK)
+			responseErr = ctx.Response().Error(markerErr.Code(), markerErr.Error(), jsonStatusCode)
 		} else {
 			//屏蔽错误
-			responseErr = ctx.Response().Error(markerErr.Code(), http.StatusText(http.StatusInternalServerError), http.StatusOK)
+			responseErr = ctx.Response().Error(markerErr.Code(), http.StatusText(statusCode), jsonStatusCode)
 		}
 	} else {
 		//返回文本
 		ctx.Response().Header().Set(constant.HeaderXContentTypeOptions, "nosniff")
 		if isDebug {
 			responseErr = ctx.Response().Abort(
-				http.StatusInternalServerError,
+				statusCode,
 				strings.ReplaceAll(strings.ReplaceAll(markerErr.Error(), "\n", "<br>"), "\t", "&nbsp;&nbsp;&nbsp;&nbsp;"))
 		} else {
-			responseErr = ctx.Response().Abort(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			responseErr = ctx.Response().Abort(statusCode, http.StatusText(statusCode))
 		}
 	}
 	if !isDebug {
@@ -90,6 +100,9 @@ func defaultServerErrorFunc(ctx *Ctx, markerErr *errors.MrKErr) {
 
 //客户端错误处理
 func defaultClientErrorFunc(ctx *Ctx, markerErr *errors.MrKErr) {
+	if streamErrorFunc(ctx, markerErr) {
+		return
+	}
 	ctx.Response().Buffer().Reset()
 	isJSON := (!ctx.Request().AcceptText() || (ctx.Route() != nil && ctx.Route().HasLabel("json")))
 	var responseErr error
@@ -117,6 +130,25 @@ func defaultClientErrorFunc(ctx *Ctx, markerErr *errors.MrKErr) {
 	}
 }
 
+//流式响应已经提交了header，或者是连接已经被劫持，错误无法再响应给客户端，只记录日志
+func streamErrorFunc(ctx *Ctx, markerErr *errors.MrKErr) bool {
+	if !ctx.Response().Streaming() && !ctx.Response().Hijacked() {
+		return false
+	}
+	log.Println(fmt.Sprintf(
+		"%s%s %s%s %s%d%s %s",
+		"[",
+		ctx.Request().Raw().Method,
+		ctx.Request().Raw().URL.String(),
+		"]",
+		"[",
+		markerErr.Code(),
+		"]",
+		markerErr.Error(),
+	))
+	return true
+}
+
 //错误处理
 func defaultErrorFunc(ctx *Ctx, err error) {
 	if err == nil {
@@ -147,3 +179,33 @@ func defaultRoute(ctx *Ctx, w *Response, r *Request) error {
 		return w.HTML(http.StatusNotFound, "404 route not found")
 	}
 }
+
+//默认的请求方法不被允许的错误处理
+func defaultMethodNotAllowedRoute(ctx *Ctx, w *Response, r *Request) error {
+	ctx.Response().Buffer().Reset()
+	isJSON := (!ctx.Request().AcceptText() || (ctx.Route() != nil && ctx.Route().HasLabel("json")))
+	if isJSON {
+		//返回json
+		return ctx.Response().Error(errors.ClientCode, "405 method not allowed", http.StatusMethodNotAllowed)
+	} else {
+		//返回文本
+		ctx.Response().Header().Set(constant.HeaderXContentTypeOptions, "nosniff")
+		return w.HTML(http.StatusMethodNotAllowed, "405 method not allowed")
+	}
+}
+
+//默认的OPTIONS请求处理，响应头Allow在路由匹配时已经设置
+func defaultOptionsRoute(ctx *Ctx, w *Response, r *Request) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+//默认的重定向到规范路径的处理，响应头Location在路由匹配时已经设置
+func defaultRedirectRoute(ctx *Ctx, w *Response, r *Request) error {
+	if r.IsMethod(http.MethodGet) || r.IsMethod(http.MethodHead) {
+		w.WriteHeader(http.StatusMovedPermanently)
+	} else {
+		w.WriteHeader(http.StatusPermanentRedirect)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package slim
+
+import (
+	"net/http"
+	"strings"
+)
+
+//已注册的请求方法，路由与全局中间件只接受已注册的请求方法
+var methods = []string{
+	http.MethodOptions,
+	http.MethodHead,
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPatch,
+	http.MethodPut,
+	http.MethodDelete,
+	http.MethodTrace,
+	http.MethodConnect,
+}
+
+//注册扩展的请求方法，比如WebDAV的PROPFIND、MKCOL、LOCK，或者是PURGE、QUERY
+//注册后 Any 以及不指定请求方法的全局中间件同样包含这些请求方法
+//不是并发安全的，需要在创建App与注册路由之前调用
+func RegisterMethod(method ...string) {
+	for _, v := range method {
+		v = strings.ToUpper(v)
+		if !isToken(v) {
+			panic("invalid http method: " + v)
+		}
+		if !isMethod(v) {
+			methods = append(methods, v)
+		}
+	}
+}
+
+//返回全部已注册的请求方法
+func Methods() []string {
+	return append(make([]string, 0, len(methods)), methods...)
+}
+
+//判断请求方法是否已注册
+func isMethod(method string) bool {
+	for _, v := range methods {
+		if v == method {
+			return true
+		}
+	}
+	return false
+}
+
+//判断是否为合法的请求方法名称，即rfc7230中的token
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' {
+			continue
+		}
+		if strings.IndexByte("!#$%&'*+-.^_`|~", c) == -1 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package slim
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//测试注册扩展的请求方法
+func TestRegisterMethod(t *testing.T) {
+	RegisterMethod("purge", "PURGE")
+	if !isMethod("PURGE") || strings.Count(strings.Join(Methods(), ","), "PURGE") != 1 {
+		t.Fatal("TestRegisterMethod fatal", Methods())
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("TestRegisterMethod invalid fatal")
+			}
+		}()
+		RegisterMethod("BAD METHOD")
+	}()
+
+	app := New(true)
+	app.Use(func(ctx *Ctx, w *Response, r *Request) {
+		w.Header().Set("X-Middleware", "1")
+		ctx.Next()
+	})
+	mux := app.Mux()
+	mux.Add("/cache/*path", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "purge "+r.Param("path"))
+	}, "purge")
+	mux.Any("/any", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "any "+r.Raw().Method)
+	})
+	mux.Any("/some", func(ctx *Ctx, w *Response, r *Request) error {
+		return nil
+	}, http.MethodGet, "PURGE")
+
+	rec := serve(app, "PURGE", "/cache/a/b")
+	if rec.Body.String() != "purge a/b" || rec.Header().Get("X-Middleware") != "1" {
+		t.Fatal("TestRegisterMethod route fatal", rec.Body.String(), rec.Header())
+	}
+	if rec := serve(app, "PURGE", "/any"); rec.Body.String() != "any PURGE" {
+		t.Fatal("TestRegisterMethod any fatal", rec.Body.String())
+	}
+	//未注册的请求方法
+	if rec := serve(app, "MKCOL", "/any"); rec.Body.String() == "any MKCOL" {
+		t.Fatal("TestRegisterMethod unknown fatal", rec.Body.String())
+	}
+
+	dump := mux.DumpRouteMap()
+	for _, v := range strings.Split(dump, "\n") {
+		switch {
+		case strings.Contains(v, "/any"):
+			if !strings.Contains(v, "| ANY ") {
+				t.Fatal("TestRegisterMethod dump any fatal", v)
+			}
+		case strings.Contains(v, "/cache/*path"):
+			if !strings.Contains(v, "| PURGE ") {
+				t.Fatal("TestRegisterMethod dump fatal", v)
+			}
+		case strings.Contains(v, "/some"):
+			if strings.Contains(v, "ANY") || !strings.Contains(v, "| GET ") {
+				t.Fatal("TestRegisterMethod dump some fatal", v)
+			}
+		}
+	}
+}
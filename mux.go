@@ -2,8 +2,11 @@ package slim
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"github.com/buexplain/go-slim/constant"
 	"github.com/buexplain/go-slim/tree"
+	"github.com/buexplain/go-slim/tsmap"
 	"github.com/olekukonko/tablewriter"
 	"net/http"
 	"reflect"
@@ -12,51 +15,165 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type Mux struct {
-	data         map[string]*tree.Tree
-	routeMap     map[string]*Route
-	groups       []*RouteGroup
-	regexp       map[string]*regexp.Regexp
-	defaultRoute *Route
+	//路由表
+	table  *routeTable
+	groups []*RouteGroup
+	regexp map[string]*regexp.Regexp
+	//是否用GET路由自动响应HEAD请求
+	autoHead bool
+	//是否根据已注册的路由自动响应OPTIONS请求
+	autoOptions bool
+	//自动响应OPTIONS请求的路由
+	optionsRoute *Route
+	//请求路径不规范时的处理策略
+	pathPolicy PathPolicy
+	//重定向到规范路径的路由
+	redirectRoute *Route
+	//当前注册的路由是否区分大小写
+	caseSensitive bool
+	//当前正在注册路由的域名
+	host *routeHost
+	//是否已经冻结，冻结后不允许再修改路由
+	frozen bool
+	//挂载的 *slim.App，冻结时一并冻结
+	mounts []*App
+	//被挂载到上级时的挂载路由，用于生成包含挂载前缀的url
+	mount *Route
+	//是否开启动态模式，开启后可以在处理请求的同时修改路由
+	dynamic bool
+	//动态模式下供请求读取的路由表副本
+	current atomic.Value
+	//动态模式下修改路由表的锁
+	lock sync.Mutex
+	//已注册的路由数量，用于生成路由的默认名称
+	seq int
 }
 
 func NewMux() *Mux {
 	tmp := new(Mux)
-	tmp.data = map[string]*tree.Tree{
-		http.MethodOptions: tree.New(false),
-		http.MethodHead:    tree.New(false),
-		http.MethodGet:     tree.New(false),
-		http.MethodPost:    tree.New(false),
-		http.MethodPatch:   tree.New(false),
-		http.MethodPut:     tree.New(false),
-		http.MethodDelete:  tree.New(false),
-		http.MethodTrace:   tree.New(false),
-		http.MethodConnect: tree.New(false),
-	}
-	tmp.routeMap = make(map[string]*Route)
+	tmp.table = newRouteTable()
 	tmp.groups = make([]*RouteGroup, 0)
 	tmp.regexp = make(map[string]*regexp.Regexp)
 	tmp.SetDefaultRoute(defaultRoute)
+	tmp.SetMethodNotAllowedRoute(defaultMethodNotAllowedRoute)
+	tmp.optionsRoute = NewRoute(tmp, "", nil, defaultOptionsRoute)
+	tmp.redirectRoute = NewRoute(tmp, "", nil, defaultRedirectRoute)
 	return tmp
 }
 
+//每个请求方法对应的路由树，区分大小写与不区分大小写的路由分开存储
+type methodTrees struct {
+	strict map[string]*tree.Tree
+	fold   map[string]*tree.Tree
+}
+
+//新建每个请求方法对应的路由树
+func newMethodTrees() *methodTrees {
+	tmp := &methodTrees{strict: map[string]*tree.Tree{}, fold: map[string]*tree.Tree{}}
+	for _, method := range methods {
+		tmp.strict[method] = tree.New(true)
+		tmp.fold[method] = tree.New(false)
+	}
+	return tmp
+}
+
+//添加路由到对应请求方法的路由树
+func (this *methodTrees) add(method string, route *Route) error {
+	//创建路由表之后注册的请求方法
+	if _, ok := this.fold[method]; !ok {
+		this.strict[method] = tree.New(true)
+		this.fold[method] = tree.New(false)
+	}
+	if route.strict {
+		return this.strict[method].Add(route.path, route)
+	}
+	return this.fold[method].Add(route.path, route)
+}
+
+//从对应请求方法的路由树中移除路由，树中存储的不是该路由则不做处理
+func (this *methodTrees) remove(method string, route *Route) {
+	trees := this.fold
+	if route.strict {
+		trees = this.strict
+	}
+	if currTree, ok := trees[method]; ok {
+		if result, ok := currTree.Lookup(route.path); ok && result.(*Route) == route {
+			currTree.Remove(route.path)
+		}
+	}
+}
+
+//在对应请求方法的路由树中查找，区分大小写的路由优先
+func (this *methodTrees) search(method string, path string, param tree.Store) (interface{}, bool) {
+	if currTree, ok := this.strict[method]; ok {
+		if result, ok := currTree.Search(path, param); ok {
+			return result, ok
+		}
+	}
+	if currTree, ok := this.fold[method]; ok {
+		return currTree.Search(path, param)
+	}
+	return nil, false
+}
+
 func (this *Mux) AddRoute(route *Route) error {
+	if this.frozen {
+		return fmt.Errorf("mux is frozen, can not add route: %s", route.path)
+	}
+	var err error
+	this.modify(func() {
+		err = this.addRoute(route)
+	})
+	return err
+}
+
+func (this *Mux) addRoute(route *Route) error {
 	if len(this.groups) > 0 {
 		route.setPath(this.groups[len(this.groups)-1:][0].prefix + route.path)
 	}
-	for _, method := range route.methods {
-		if err := this.data[method].Add(route.path, route); err != nil {
+	pattern, params, err := tree.ParsePath(route.path)
+	if err != nil {
+		return fmt.Errorf("path: %s err: %+v", route.path, err)
+	}
+	route.pattern = pattern
+	route.params = params
+	route.strict = this.caseSensitive
+	data := this.table.data
+	if this.host != nil {
+		route.host = this.host.pattern
+		data = this.host.data
+	}
+	for i, method := range route.methods {
+		if err := data.add(method, route); err != nil {
+			//回滚已经添加的请求方法
+			for _, v := range route.methods[:i] {
+				data.remove(v, route)
+			}
 			return fmt.Errorf("method: %s path: %s err: %+v", method, route.path, err)
 		}
 	}
-	if this.groups != nil {
-		for _, v := range this.groups {
-			v.addRoute(route)
+	route.groups = append(make([]*RouteGroup, 0, len(this.groups)), this.groups...)
+	for _, v := range this.groups {
+		v.addRoute(route)
+		route.addLabel(v.label...)
+	}
+	//内层路由组的正则优先
+	for i := len(this.groups) - 1; i >= 0; i-- {
+		for key, re := range this.groups[i].regexp {
+			if _, ok := route.regexp[key]; !ok {
+				route.regexp[key] = re
+			}
 		}
 	}
-	route.SetName(strconv.Itoa(len(this.routeMap)))
+	route.rebuild()
+	route.seq = this.seq
+	this.seq++
+	route.setName(strconv.Itoa(route.seq))
 	return nil
 }
 
@@ -134,49 +251,68 @@ func (this *Mux) Any(path string, handler Handler, methods ...string) RouteSetIn
 }
 
 func (this *Mux) Restful(path string, handler ...Handler) RouteSetInterface {
-	routeRestful := &RouteRestful{data: []*Route{}}
+	actions := ResourceActions{}
 	for _, h := range handler {
-		fn := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+		fn := strings.TrimSuffix(runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name(), "-fm")
 		tmp := strings.LastIndex(fn, ".")
 		if tmp == -1 {
 			continue
 		}
 		fn = fn[tmp+1:]
-		switch true {
-		case strings.EqualFold(fn, "index"):
-			route := this.Get(path, h)
-			routeRestful.data = append(routeRestful.data, route.(*Route))
-			break
-		case strings.EqualFold(fn, "create"):
-			route := this.Get(strings.TrimRight(path, "/")+"/create", h)
-			routeRestful.data = append(routeRestful.data, route.(*Route))
-			break
-		case strings.EqualFold(fn, "store"):
-			route := this.Post(path, h)
-			routeRestful.data = append(routeRestful.data, route.(*Route))
-			break
-		case strings.EqualFold(fn, "edit"):
-			route := this.Get(strings.TrimRight(path, "/")+"/edit/:id", h)
-			routeRestful.data = append(routeRestful.data, route.(*Route))
-			break
-		case strings.EqualFold(fn, "update"):
-			route := this.Put(strings.TrimRight(path, "/")+"/update/:id", h)
-			routeRestful.data = append(routeRestful.data, route.(*Route))
-			break
-		case strings.EqualFold(fn, "destroy"):
-			route := this.Delete(strings.TrimRight(path, "/")+"/delete/:id", h)
-			routeRestful.data = append(routeRestful.data, route.(*Route))
-			break
-		case strings.EqualFold(fn, "show"):
-			route := this.Get(strings.TrimRight(path, "/")+"/show/:id", h)
-			routeRestful.data = append(routeRestful.data, route.(*Route))
-			break
+		for _, action := range resourceActions {
+			if strings.EqualFold(fn, action) {
+				actions[action] = h
+				break
+			}
 		}
 	}
+	return this.Resource(path, actions, ResourceStyleClassic)
+}
+
+//注册资源路由，路径的风格默认是 ResourceStyleClassic，资源的参数名称为id
+func (this *Mux) Resource(path string, actions ResourceActions, style ...ResourceStyle) *RouteRestful {
+	path = strings.Trim(path, "/")
+	if path != "" {
+		path = "/" + path
+	}
+	routeRestful := &RouteRestful{mux: this, style: ResourceStyleClassic, host: this.host, strict: this.caseSensitive}
+	if len(style) > 0 {
+		routeRestful.style = style[0]
+	}
+	//记录包含路由组前缀的完整路径，嵌套的资源不依赖调用时所在的路由组
+	routeRestful.groups = append(make([]*RouteGroup, 0, len(this.groups)), this.groups...)
+	routeRestful.path = path
+	if len(this.groups) > 0 {
+		routeRestful.path = this.groups[len(this.groups)-1].prefix + path
+	}
+	for _, action := range resourceActions {
+		h, ok := actions[action]
+		if !ok || h == nil {
+			continue
+		}
+		methods, p := resourceRoute(action, routeRestful.style, path, "/:id")
+		route := this.Any(p, h, methods...)
+		routeRestful.data = append(routeRestful.data, route.(*Route))
+		routeRestful.actions = append(routeRestful.actions, action)
+	}
 	return routeRestful
 }
 
-func (this *Mux) Group(prefix string, f func()) *RouteGroup {
+//注册路由组，f可以是 func() 或者是 func(*RouteGroup)
+//在f中对路由组设置的中间件、标签、正则，对组内之后注册的路由以及嵌套的路由组同样生效
+//中间件按路由组从外到内的顺序执行，同一个路由组内按设置的顺序执行，最后执行路由自身的中间件
+func (this *Mux) Group(prefix string, f interface{}) *RouteGroup {
+	var fn func(g *RouteGroup)
+	switch h := f.(type) {
+	case func():
+		fn = func(g *RouteGroup) {
+			h()
+		}
+	case func(g *RouteGroup):
+		fn = h
+	default:
+		panic("unknown group func")
+	}
 	if len(this.groups) > 0 {
 		if prefix != "" && prefix != "/" {
 			prefix = this.groups[len(this.groups)-1:][0].prefix + "/" + strings.Trim(prefix, "/")
@@ -185,8 +321,15 @@ func (this *Mux) Group(prefix string, f func()) *RouteGroup {
 		}
 	}
 	g := NewRouteGroup(prefix)
+	g.mux = this
+	g.host = this.host
+	g.strict = this.caseSensitive
+	g.parents = append(make([]*RouteGroup, 0, len(this.groups)), this.groups...)
 	this.groups = append(this.groups, g)
-	f()
+	//组内设置的大小写敏感只在组内生效
+	caseSensitive := this.caseSensitive
+	fn(g)
+	this.caseSensitive = caseSensitive
 	this.groups = this.groups[:len(this.groups)-1]
 	return g
 }
@@ -196,13 +339,45 @@ func (this *Mux) Regexp(key string, pattern string) *Mux {
 	return this
 }
 
+//设置未命中路由时的路由，动态模式下同样可以在处理请求的同时设置
 func (this *Mux) SetDefaultRoute(handler Handler) RouteSetInterface {
-	this.defaultRoute = NewRoute(this, "", nil, handler)
-	return this.defaultRoute
+	route := NewRoute(this, "", nil, handler)
+	this.modify(func() {
+		this.table.defaultRoute = route
+	})
+	return route
+}
+
+//设置请求路径存在，但是请求方法不匹配时的路由
+func (this *Mux) SetMethodNotAllowedRoute(handler Handler) RouteSetInterface {
+	route := NewRoute(this, "", nil, handler)
+	this.modify(func() {
+		this.table.methodNotAllowedRoute = route
+	})
+	return route
+}
+
+//设置是否用GET路由自动响应没有注册HEAD路由的HEAD请求，响应的body会被丢弃
+func (this *Mux) SetAutoHead(autoHead bool) *Mux {
+	this.autoHead = autoHead
+	return this
+}
+
+//设置是否自动响应没有注册OPTIONS路由的OPTIONS请求，响应头Allow为当前路径已注册的请求方法
+func (this *Mux) SetAutoOptions(autoOptions bool) *Mux {
+	this.autoOptions = autoOptions
+	return this
+}
+
+//设置之后注册的路由是否区分大小写，默认不区分，在路由组内设置只对组内的路由生效
+//不论是否区分大小写，路由参数的值总是保持请求路径中的原始大小写
+func (this *Mux) SetCaseSensitive(caseSensitive bool) *Mux {
+	this.caseSensitive = caseSensitive
+	return this
 }
 
 func (this *Mux) GetRouteByName(name string) RouteGetInterface {
-	if r, ok := this.routeMap[name]; ok {
+	if r, ok := this.routes().routeMap[name]; ok {
 		return r
 	}
 	return nil
@@ -212,9 +387,11 @@ func (this *Mux) GetRouteMap(packageName ...string) RouteShadowSlice {
 	if len(packageName) == 0 {
 		packageName = append(packageName, "")
 	}
-	shadows := make(RouteShadowSlice, 0, len(this.routeMap))
-	for _, route := range this.routeMap {
+	routeMap := this.routes().routeMap
+	shadows := make(RouteShadowSlice, 0, len(routeMap))
+	for _, route := range routeMap {
 		shadow := RouteShadow{}
+		shadow.Host = route.host
 		shadow.Path = route.path
 		shadow.Methods = route.methods
 		shadow.Middleware = make([]string, 0, len(route.middleware))
@@ -228,6 +405,13 @@ func (this *Mux) GetRouteMap(packageName ...string) RouteShadowSlice {
 		for k, v := range route.regexp {
 			shadow.Regexp[k] = v.String()
 		}
+		shadow.Params = make([]RouteShadowParam, 0, len(route.params))
+		for _, v := range route.params {
+			shadow.Params = append(shadow.Params, RouteShadowParam{Kind: string(v.Kind), Name: v.Name, Constraint: v.Constraint})
+		}
+		shadow.Request = route.request
+		shadow.Response = route.response
+		shadow.mount = route.mount != nil
 		shadows = append(shadows, shadow)
 	}
 	sort.Sort(shadows)
@@ -253,35 +437,133 @@ func (this *Mux) DumpRouteMap(packageName ...string) string {
 			reg.WriteString(v2)
 		}
 		var methods string = "ANY"
-		if len(v.Methods) != 9 {
+		if !isAnyMethods(v.Methods) {
 			methods = strings.Join(v.Methods, "\n")
 		}
-		tmp := []string{strconv.Itoa(k + 1), v.Path, methods, strings.Join(v.Middleware, "\n"), v.Handler, v.Name, strings.Join(v.Label, "\n"), reg.String()}
+		tmp := []string{strconv.Itoa(k + 1), v.Host + v.Path, methods, strings.Join(v.Middleware, "\n"), v.Handler, v.Name, strings.Join(v.Label, "\n"), reg.String()}
 		table.Append(tmp)
 	}
 	table.Render()
 	return buf.String()
 }
 
-func (this *Mux) match(ctx *Ctx) *Route {
-	if currTree, ok := this.data[ctx.r.r.Method]; ok {
-		if result, ok := currTree.Search(ctx.Path(), ctx.r.param); ok {
-			if route, ok := result.(*Route); ok {
-				for k, v := range route.regexp {
-					if ctx.r.HasParam(k) {
-						if v.MatchString(ctx.r.Param(k)) == false {
-							return this.defaultRoute
-						}
-					}
+//以json格式导出全部路由，不包含挂载的路由
+func (this *Mux) DumpRouteMapJSON(packageName ...string) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(buf)
+	//路径中的参数约束包含尖括号，不做转义
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	//挂载的路由是通配的路径，不是真实的接口
+	shadows := this.GetRouteMap(packageName...)
+	tmp := shadows[:0]
+	for _, v := range shadows {
+		if !v.mount {
+			tmp = append(tmp, v)
+		}
+	}
+	if err := encoder.Encode(tmp); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//查找未命中路由时的路由，优先使用路径前缀最长的路由组设置的
+func (this *Mux) notFound(table *routeTable, host string, path string) *Route {
+	for _, route := range table.defaults {
+		if route.host != host {
+			continue
+		}
+		if route.path == "/" {
+			return route
+		}
+		if len(path) < len(route.path) || (len(path) > len(route.path) && path[len(route.path)] != '/') {
+			continue
+		}
+		if path[:len(route.path)] == route.path || (!route.strict && strings.EqualFold(path[:len(route.path)], route.path)) {
+			return route
+		}
+	}
+	return table.defaultRoute
+}
+
+//判断是否包含了全部已注册的请求方法
+func isAnyMethods(list []string) bool {
+	if len(list) != len(methods) {
+		return false
+	}
+	for _, v := range list {
+		if !isMethod(v) {
+			return false
+		}
+	}
+	return true
+}
+
+//在指定请求方法的路由树中查找路由
+func (this *Mux) lookup(data *methodTrees, method string, path string, param *tsmap.TSMap) *Route {
+	result, ok := data.search(method, path, param)
+	if !ok {
+		return nil
+	}
+	route, ok := result.(*Route)
+	if !ok {
+		return nil
+	}
+	for k, v := range route.regexp {
+		if param.Has(k) {
+			if s, ok := param.Get(k).(string); ok && v.MatchString(s) == false {
+				//移除本次搜索到的参数
+				for _, p := range route.params {
+					param.Del(p.Name)
 				}
-				return route
-			} else {
-				return this.defaultRoute
+				return nil
 			}
-		} else {
-			return this.defaultRoute
 		}
-	} else {
-		return this.defaultRoute
 	}
+	return route
+}
+
+//返回请求路径能够匹配的请求方法
+func (this *Mux) allow(data *methodTrees, path string) []string {
+	methods := make([]string, 0, len(data.fold))
+	param := tsmap.New()
+	for method := range data.fold {
+		if this.lookup(data, method, path, param) != nil {
+			methods = append(methods, method)
+		} else if method == http.MethodHead && this.autoHead && this.lookup(data, http.MethodGet, path, param) != nil {
+			methods = append(methods, method)
+		}
+		param.Release()
+	}
+	sort.Strings(methods)
+	if len(methods) > 0 && this.autoOptions {
+		if i := sort.SearchStrings(methods, http.MethodOptions); i == len(methods) || methods[i] != http.MethodOptions {
+			methods = append(methods, http.MethodOptions)
+			sort.Strings(methods)
+		}
+	}
+	return methods
+}
+
+func (this *Mux) match(ctx *Ctx) *Route {
+	table := this.routes()
+	data, host := this.matchHost(table, ctx)
+	route := this.lookup(data, ctx.r.r.Method, ctx.Path(), ctx.r.param)
+	//HEAD请求复用GET路由，body在响应时被丢弃
+	if route == nil && this.autoHead && ctx.r.r.Method == http.MethodHead {
+		route = this.lookup(data, http.MethodGet, ctx.Path(), ctx.r.param)
+	}
+	if route != nil {
+		return this.checkPath(table, host, ctx, route)
+	}
+	//请求路径在其它请求方法下存在，响应405
+	if methods := this.allow(data, ctx.Path()); len(methods) > 0 {
+		ctx.w.Header().Set(constant.HeaderAllow, strings.Join(methods, ", "))
+		if this.autoOptions && ctx.r.r.Method == http.MethodOptions {
+			return this.optionsRoute
+		}
+		return table.methodNotAllowedRoute
+	}
+	return this.notFound(table, host, ctx.Path())
 }
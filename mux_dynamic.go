@@ -0,0 +1,164 @@
+package slim
+
+import (
+	"fmt"
+	"github.com/buexplain/go-slim/tree"
+)
+
+//路由表
+type routeTable struct {
+	//默认的路由集合
+	data *methodTrees
+	//路由名称与路由的映射
+	routeMap map[string]*Route
+	//按域名划分的路由集合
+	hosts *tree.Tree
+	//域名模式与路由集合的映射
+	hostMap map[string]*routeHost
+	//路由组设置的未命中路由时的路由，按路径从长到短排列
+	defaults []*Route
+	//未命中路由时的路由
+	defaultRoute *Route
+	//请求路径存在，但是请求方法不匹配时的路由
+	methodNotAllowedRoute *Route
+}
+
+func newRouteTable() *routeTable {
+	return &routeTable{
+		data:     newMethodTrees(),
+		routeMap: make(map[string]*Route),
+		hosts:    tree.New(false),
+		hostMap:  make(map[string]*routeHost),
+	}
+}
+
+//复制路由表，路由也会被复制，复制后的路由表与原路由表互不影响
+func (this *routeTable) clone() *routeTable {
+	tmp := &routeTable{
+		routeMap: make(map[string]*Route, len(this.routeMap)),
+		hostMap:  make(map[string]*routeHost, len(this.hostMap)),
+	}
+	routes := make(map[*Route]*Route, len(this.routeMap))
+	for name, route := range this.routeMap {
+		routes[route] = route.clone()
+		tmp.routeMap[name] = routes[route]
+	}
+	cloneRoute := func(data interface{}) interface{} {
+		return routes[data.(*Route)]
+	}
+	tmp.data = this.data.clone(cloneRoute)
+	hosts := make(map[*routeHost]*routeHost, len(this.hostMap))
+	for pattern, host := range this.hostMap {
+		hosts[host] = &routeHost{pattern: host.pattern, data: host.data.clone(cloneRoute)}
+		tmp.hostMap[pattern] = hosts[host]
+	}
+	tmp.hosts = this.hosts.Clone(func(data interface{}) interface{} {
+		return hosts[data.(*routeHost)]
+	})
+	tmp.defaults = make([]*Route, 0, len(this.defaults))
+	for _, route := range this.defaults {
+		tmp.defaults = append(tmp.defaults, route.clone())
+	}
+	tmp.defaultRoute = this.defaultRoute.clone()
+	tmp.methodNotAllowedRoute = this.methodNotAllowedRoute.clone()
+	return tmp
+}
+
+//添加路由组的未命中路由时的路由，路径相同的替换掉之前的
+func (this *routeTable) addDefault(route *Route) {
+	for k, v := range this.defaults {
+		if v.host == route.host && v.path == route.path {
+			this.defaults[k] = route
+			return
+		}
+	}
+	i := len(this.defaults)
+	for k, v := range this.defaults {
+		if len(v.path) < len(route.path) {
+			i = k
+			break
+		}
+	}
+	this.defaults = append(this.defaults, nil)
+	copy(this.defaults[i+1:], this.defaults[i:])
+	this.defaults[i] = route
+}
+
+//复制每个请求方法对应的路由树
+func (this *methodTrees) clone(f func(data interface{}) interface{}) *methodTrees {
+	tmp := &methodTrees{strict: make(map[string]*tree.Tree, len(this.strict)), fold: make(map[string]*tree.Tree, len(this.fold))}
+	for method, v := range this.strict {
+		tmp.strict[method] = v.Clone(f)
+	}
+	for method, v := range this.fold {
+		tmp.fold[method] = v.Clone(f)
+	}
+	return tmp
+}
+
+//设置是否开启动态模式，需要在开始处理请求之前设置
+//动态模式下可以在处理请求的同时添加、删除、修改路由，每次修改都会复制一份新的路由表供请求读取，查找路由时无需加锁
+//修改路由的开销随路由数量增长，适合插件启停这类低频的修改；动态模式下Freeze只校验不冻结
+//链式设置路由时，每一步都会立即生效，需要一次性生效的可以先 NewRoute 设置好之后再 AddRoute
+//路由组与域名的注册过程不是并发安全的，多个协程同时注册路由组需要自行加锁
+func (this *Mux) SetDynamic(dynamic bool) *Mux {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.dynamic = dynamic
+	if dynamic {
+		this.current.Store(this.table.clone())
+	}
+	return this
+}
+
+//是否开启了动态模式
+func (this *Mux) Dynamic() bool {
+	return this.dynamic
+}
+
+//返回供请求读取的路由表
+func (this *Mux) routes() *routeTable {
+	if this.dynamic {
+		return this.current.Load().(*routeTable)
+	}
+	return this.table
+}
+
+//修改路由表，动态模式下加锁修改，修改完成后发布路由表的副本
+func (this *Mux) modify(f func()) {
+	this.checkFrozen()
+	if !this.dynamic {
+		f()
+		return
+	}
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	defer func() {
+		this.current.Store(this.table.clone())
+	}()
+	f()
+}
+
+//根据名称删除路由，冻结后不允许删除
+func (this *Mux) Remove(name string) error {
+	if this.frozen {
+		return fmt.Errorf("mux is frozen, can not remove route: %s", name)
+	}
+	var err error
+	this.modify(func() {
+		route, ok := this.table.routeMap[name]
+		if !ok {
+			err = fmt.Errorf("route not found: %s", name)
+			return
+		}
+		data := this.table.data
+		if route.host != "" {
+			data = this.table.hostMap[route.host].data
+		}
+		for _, method := range route.methods {
+			data.remove(method, route)
+		}
+		delete(this.table.routeMap, name)
+	})
+	return err
+}
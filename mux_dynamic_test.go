@@ -0,0 +1,72 @@
+package slim
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+//测试动态模式下处理请求的同时修改路由，需要配合 -race 运行
+func TestDynamicConcurrent(t *testing.T) {
+	app := New(true)
+	mux := app.Mux()
+	mux.SetDynamic(true)
+	var group *RouteGroup
+	var route RouteSetInterface
+	mux.Group("/api", func(g *RouteGroup) {
+		group = g
+		route = mux.Get("/ping", func(ctx *Ctx, w *Response, r *Request) error {
+			return w.Plain(http.StatusOK, "pong")
+		})
+	})
+	middleware := func(ctx *Ctx, w *Response, r *Request) {
+		ctx.Next()
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if rec := serve(app, http.MethodGet, "/api/ping"); rec.Code != http.StatusOK || rec.Body.String() != "pong" {
+					t.Error("TestDynamicConcurrent fatal", rec.Code, rec.Body.String())
+					return
+				}
+				serve(app, http.MethodGet, "/dynamic/0")
+				serve(app, http.MethodPost, "/api/ping")
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		name := "dynamic" + strconv.Itoa(i)
+		tmp := NewRoute(mux, "/dynamic/"+strconv.Itoa(i%5), []string{http.MethodGet}, func(ctx *Ctx, w *Response, r *Request) error {
+			return w.Plain(http.StatusOK, "dynamic")
+		})
+		if err := mux.AddRoute(tmp); err != nil {
+			t.Fatal("TestDynamicConcurrent fatal", err)
+		}
+		tmp.SetName(name)
+		route.Use(middleware)
+		group.Use(middleware)
+		mux.SetDefaultRoute(defaultRoute).Use(middleware)
+		mux.SetMethodNotAllowedRoute(defaultMethodNotAllowedRoute).Use(middleware)
+		if err := mux.Remove(name); err != nil {
+			t.Fatal("TestDynamicConcurrent fatal", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if rec := serve(app, http.MethodGet, "/dynamic/0"); rec.Body.String() == "dynamic" {
+		t.Fatal("TestDynamicConcurrent remove fatal", rec.Body.String())
+	}
+}
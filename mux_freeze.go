@@ -0,0 +1,160 @@
+package slim
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//校验路由时发现的全部问题
+type RouteErrors []error
+
+func (this RouteErrors) Error() string {
+	tmp := make([]string, 0, len(this))
+	for _, v := range this {
+		tmp = append(tmp, v.Error())
+	}
+	return strings.Join(tmp, "\n")
+}
+
+//正则被路由使用的情况
+type regexpUsage struct {
+	key   string
+	paths []string
+	used  bool
+}
+
+//冻结后修改路由则panic
+func (this *Mux) checkFrozen() {
+	if this.frozen {
+		panic("mux is frozen, can not modify route")
+	}
+}
+
+//是否已经冻结
+func (this *Mux) Frozen() bool {
+	return this.frozen
+}
+
+//校验全部路由，返回发现的全部问题，没有问题返回nil
+//挂载的 *slim.App 的路由也会被校验
+//校验的内容：同一个具名的处理函数以同一个请求方法注册到多条路由、路径中不存在的参数的正则、与自动生成的数字名称冲突的路由名称
+//同路径同请求方法的重复注册在注册时就会失败，不需要在这里校验
+func (this *Mux) Validate() error {
+	var errs RouteErrors
+	//路由组的正则会被组内的每一条路由共用，只要有一条路由用到了就不算未使用
+	usages := map[*regexp.Regexp]*regexpUsage{}
+	order := make([]*regexp.Regexp, 0)
+	table := this.routes()
+	routes := make([]*Route, 0, len(table.routeMap))
+	for _, route := range table.routeMap {
+		routes = append(routes, route)
+	}
+	//按注册顺序报告问题
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].seq < routes[j].seq
+	})
+	//同一个具名的处理函数以同一个请求方法注册到了多条路由
+	handlers := map[string]*Route{}
+	for _, route := range routes {
+		if handler := handlerName(route); handler != "" {
+			for _, method := range route.methods {
+				key := route.host + " " + method + " " + handler
+				if first, ok := handlers[key]; ok {
+					errs = append(errs, fmt.Errorf("route %s %s%s duplicates handler %s of route %s %s%s", method, route.host, route.path, handler, first.name, first.host, first.path))
+				} else {
+					handlers[key] = route
+				}
+			}
+		}
+		keys := make([]string, 0, len(route.regexp))
+		for key := range route.regexp {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			//全局正则会复制给每一条路由，不要求每条路由都有对应的参数
+			if _, ok := this.regexp[key]; ok {
+				continue
+			}
+			re := route.regexp[key]
+			usage, ok := usages[re]
+			if !ok {
+				usage = &regexpUsage{key: key}
+				usages[re] = usage
+				order = append(order, re)
+			}
+			usage.paths = append(usage.paths, route.host+route.path)
+			for _, param := range route.params {
+				if param.Name == key {
+					usage.used = true
+					break
+				}
+			}
+		}
+		if route.named {
+			if _, err := strconv.Atoi(route.name); err == nil {
+				errs = append(errs, fmt.Errorf("route %s%s name %s clashes with auto-generated numeric names", route.host, route.path, route.name))
+			}
+		}
+	}
+	for _, re := range order {
+		if usage := usages[re]; !usage.used {
+			errs = append(errs, fmt.Errorf("regexp %s is unused, param not found in route %s", usage.key, strings.Join(usage.paths, ", ")))
+		}
+	}
+	for _, app := range this.mounts {
+		if err := app.mux.Validate(); err != nil {
+			for _, v := range err.(RouteErrors) {
+				errs = append(errs, fmt.Errorf("mount: %s", v))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+//返回路由的处理函数的名称，闭包与方法值的代码地址是共用的，无法区分，返回空字符串
+func handlerName(route *Route) string {
+	if route.mount != nil || route.handler == nil {
+		return ""
+	}
+	name := runtime.FuncForPC(reflect.ValueOf(route.handler).Pointer()).Name()
+	if strings.HasSuffix(name, "-fm") || funcLiteral.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+//闭包的名称，比如 main.main.func1
+var funcLiteral = regexp.MustCompile(`\.func\d+(\.\d+)*$`)
+
+//校验并冻结路由，校验不通过时返回全部问题并且不冻结
+//冻结后添加或修改路由会失败，路由表不再变化，处理请求时无需加锁
+//挂载的 *slim.App 会一并校验与冻结
+func (this *Mux) Freeze() error {
+	if this.frozen {
+		return nil
+	}
+	if err := this.Validate(); err != nil {
+		return err
+	}
+	this.freeze()
+	return nil
+}
+
+func (this *Mux) freeze() {
+	for _, app := range this.mounts {
+		app.mux.freeze()
+	}
+	//动态模式下只校验不冻结
+	if !this.dynamic {
+		this.frozen = true
+	}
+}
@@ -0,0 +1,111 @@
+package slim
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func testFreezeIndex(ctx *Ctx, w *Response, r *Request) error {
+	return w.Plain(http.StatusOK, "index")
+}
+
+func testFreezeShow(ctx *Ctx, w *Response, r *Request) error {
+	return w.Plain(http.StatusOK, "show "+r.Param("id"))
+}
+
+//测试一次性报告全部问题
+func TestValidate(t *testing.T) {
+	app := New(true)
+	mux := app.Mux()
+	mux.Get("/", testFreezeIndex)
+	mux.Get("/index", testFreezeIndex)
+	mux.Post("/index", testFreezeIndex)
+	mux.Get("/user/:id", testFreezeShow).Regexp("uid", `^\d+$`)
+	mux.Get("/about", func(ctx *Ctx, w *Response, r *Request) error {
+		return nil
+	}).SetName("100")
+	mux.Get("/contact", func(ctx *Ctx, w *Response, r *Request) error {
+		return nil
+	})
+	sub := New(true)
+	sub.Mux().Get("/a", testFreezeShow)
+	sub.Mux().Get("/b", testFreezeShow)
+	mux.Mount("/sub", sub)
+
+	err := app.Freeze()
+	errs, ok := err.(RouteErrors)
+	if !ok || len(errs) != 4 {
+		t.Fatal("TestValidate fatal", err)
+	}
+	for k, v := range []string{"GET /index duplicates handler", "name 100 clashes", "regexp uid is unused", "mount: route GET /b duplicates handler"} {
+		if !strings.Contains(errs[k].Error(), v) {
+			t.Fatal("TestValidate fatal", k, errs[k])
+		}
+	}
+	//校验不通过时不冻结
+	if mux.Frozen() || sub.Mux().Frozen() {
+		t.Fatal("TestValidate frozen fatal")
+	}
+}
+
+//测试冻结后不允许添加、删除、修改路由
+func TestFreeze(t *testing.T) {
+	app := New(true)
+	mux := app.Mux()
+	route := mux.Get("/user/:id", testFreezeShow).SetName("user.show")
+	sub := New(true)
+	sub.Mux().Get("/", testFreezeIndex)
+	mux.Mount("/sub", sub)
+	if err := mux.Validate(); err != nil {
+		t.Fatal("TestFreeze validate fatal", err)
+	}
+	if err := app.Freeze(); err != nil || !mux.Frozen() || !sub.Mux().Frozen() {
+		t.Fatal("TestFreeze fatal", err)
+	}
+	if err := app.Freeze(); err != nil {
+		t.Fatal("TestFreeze twice fatal", err)
+	}
+	if err := mux.AddRoute(NewRoute(mux, "/new", nil, testFreezeIndex)); err == nil {
+		t.Fatal("TestFreeze add fatal")
+	}
+	if err := mux.Remove("user.show"); err == nil {
+		t.Fatal("TestFreeze remove fatal")
+	}
+	for name, f := range map[string]func(){
+		"Get":        func() { mux.Get("/new", testFreezeIndex) },
+		"Use":        func() { route.Use(nil) },
+		"SetName":    func() { route.SetName("user") },
+		"Sub":        func() { sub.Mux().Get("/new", testFreezeIndex) },
+		"SetDefault": func() { mux.SetDefaultRoute(testFreezeIndex) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("TestFreeze modify fatal", name)
+				}
+			}()
+			f()
+		}()
+	}
+	//冻结后正常处理请求
+	if rec := serve(app, http.MethodGet, "/user/5"); rec.Body.String() != "show 5" {
+		t.Fatal("TestFreeze serve fatal", rec.Body.String())
+	}
+	if rec := serve(app, http.MethodGet, "/sub"); rec.Body.String() != "index" {
+		t.Fatal("TestFreeze serve fatal", rec.Body.String())
+	}
+}
+
+//测试动态模式下只校验不冻结
+func TestFreezeDynamic(t *testing.T) {
+	app := New(true)
+	app.Mux().SetDynamic(true)
+	app.Mux().Get("/", testFreezeIndex)
+	if err := app.Freeze(); err != nil || app.Mux().Frozen() {
+		t.Fatal("TestFreezeDynamic fatal", err)
+	}
+	if err := app.Mux().AddRoute(NewRoute(app.Mux(), "/new", nil, testFreezeShow)); err != nil {
+		t.Fatal("TestFreezeDynamic add fatal", err)
+	}
+}
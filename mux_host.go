@@ -0,0 +1,71 @@
+package slim
+
+import (
+	"strings"
+)
+
+//按域名划分的路由集合
+type routeHost struct {
+	//域名模式，例如：api.example.com、:tenant.example.com
+	pattern string
+	//每个请求方法对应的路由树
+	data *methodTrees
+}
+
+//将域名转为路由树可以识别的路径，域名的每一段作为路径的一段，比如 :tenant.example.com 转为 /:tenant/example/com
+//参数约束中的点号不做转换
+func hostToPath(host string) string {
+	var buf strings.Builder
+	buf.WriteByte('/')
+	depth := 0
+	for i := 0; i < len(host); i++ {
+		c := host[i]
+		switch {
+		case c == '<':
+			depth++
+		case c == '>' && depth > 0:
+			depth--
+		case c == '.' && depth == 0:
+			c = '/'
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+//注册只响应指定域名的路由，域名中的参数可以通过 Request.Param 获取
+//命中了域名的请求只在该域名下的路由中匹配，未命中任何域名的请求在默认的路由中匹配
+//f与 Mux.Group 的一致，可以是 func() 或者是 func(*RouteGroup)
+func (this *Mux) Host(pattern string, f interface{}) *RouteGroup {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	host, ok := this.table.hostMap[pattern]
+	if !ok {
+		host = &routeHost{pattern: pattern, data: newMethodTrees()}
+		var err error
+		this.modify(func() {
+			if err = this.table.hosts.Add(hostToPath(pattern), host); err == nil {
+				this.table.hostMap[pattern] = host
+			}
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	prev := this.host
+	this.host = host
+	defer func() {
+		this.host = prev
+	}()
+	return this.Group("", f)
+}
+
+//根据请求的域名选择路由集合，并提取域名中的参数，同时返回命中的域名模式
+func (this *Mux) matchHost(table *routeTable, ctx *Ctx) (*methodTrees, string) {
+	if len(table.hostMap) == 0 {
+		return table.data, ""
+	}
+	if result, ok := table.hosts.Search(hostToPath(ctx.r.Host()), ctx.r.param); ok {
+		return result.(*routeHost).data, result.(*routeHost).pattern
+	}
+	return table.data, ""
+}
@@ -0,0 +1,65 @@
+package slim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+//发起一个指定域名的请求，返回响应的内容
+func serveHost(handler http.Handler, host string, target string) string {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Host = host
+	handler.ServeHTTP(rec, req)
+	return rec.Body.String()
+}
+
+//测试按域名划分路由
+func TestHost(t *testing.T) {
+	app := New(true)
+	mux := app.Mux()
+	mux.Host("api.example.com", func() {
+		mux.Get("/", func(ctx *Ctx, w *Response, r *Request) error {
+			return w.Plain(http.StatusOK, "api index")
+		})
+		mux.Get("/users/:id", func(ctx *Ctx, w *Response, r *Request) error {
+			return w.Plain(http.StatusOK, "api user "+r.Param("id"))
+		})
+	})
+	mux.Host(":tenant.example.com", func(g *RouteGroup) {
+		mux.Get("/", func(ctx *Ctx, w *Response, r *Request) error {
+			return w.Plain(http.StatusOK, "tenant "+r.Param("tenant"))
+		})
+	})
+	mux.Get("/", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "default index")
+	})
+	mux.Get("/only-default", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "default only")
+	})
+
+	for _, v := range [][3]string{
+		//同一个路径在不同的域名下
+		{"api.example.com", "/", "api index"},
+		{"acme.example.com", "/", "tenant acme"},
+		{"example.com", "/", "default index"},
+		//带端口的域名
+		{"api.example.com:8080", "/users/5", "api user 5"},
+		//域名不区分大小写，参数保持原始大小写
+		{"API.Example.COM", "/users/5", "api user 5"},
+		{"AcMe.example.com:8080", "/", "tenant AcMe"},
+		//未命中任何域名的请求在默认的路由中匹配
+		{"other.org", "/only-default", "default only"},
+		{"127.0.0.1:8080", "/", "default index"},
+		{"", "/", "default index"},
+	} {
+		if body := serveHost(app, v[0], v[1]); body != v[2] {
+			t.Fatal("TestHost fatal", v[0], v[1], body)
+		}
+	}
+	//命中了域名的请求不会回退到默认的路由
+	if body := serveHost(app, "api.example.com", "/only-default"); body == "default only" {
+		t.Fatal("TestHost fallback fatal", body)
+	}
+}
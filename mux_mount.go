@@ -0,0 +1,83 @@
+package slim
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+//挂载路由的通配参数名称
+const mountParam = "slimMountPath"
+
+//将一个http.Handler挂载到指定的前缀下，比如另一个 *slim.App 或者是第三方的路由
+//请求路径中的前缀会被移除后再交给handler处理，被挂载的 *slim.App 保留自己的全局中间件、错误处理、模板
+//被挂载的 *slim.App 生成的url会加上挂载的前缀，同一个 *slim.App 挂载到多处时，以最后一次挂载的前缀为准
+func (this *Mux) Mount(prefix string, handler http.Handler) RouteSetInterface {
+	prefix = strings.Trim(prefix, "/")
+	path := "/*" + mountParam
+	if prefix != "" {
+		path = "/" + prefix + path
+	}
+	route := NewRoute(this, path, nil, func(ctx *Ctx, w *Response, r *Request) error {
+		handler.ServeHTTP(w, stripPrefix(r.Raw(), strings.Count(ctx.route.pattern, "/")-1))
+		return nil
+	})
+	route.mount = handler
+	if err := this.AddRoute(route); err != nil {
+		panic(err)
+	}
+	if app, ok := handler.(*App); ok {
+		this.mounts = append(this.mounts, app)
+		app.mux.mount = route
+	}
+	return route
+}
+
+//请求的context.Context中，存储被移除的挂载前缀的键
+type mountPrefixKey struct{}
+
+//返回请求被挂载时移除的路径前缀，多层挂载的前缀会拼接在一起，未被挂载则返回空字符串
+func mountPrefix(r *http.Request) string {
+	prefix, _ := r.Context().Value(mountPrefixKey{}).(string)
+	return prefix
+}
+
+//拼接挂载前缀，并合并其中连续的斜杠，避免生成 //host 形式的地址
+func joinMountPrefix(parent string, prefix string) string {
+	if prefix == "" {
+		return parent
+	}
+	return parent + path.Clean("/"+prefix)
+}
+
+//移除请求路径的前n段，返回一个新的请求，被移除的前缀记录在新请求的context.Context中
+func stripPrefix(r *http.Request, n int) *http.Request {
+	original := r.URL.EscapedPath()
+	path := original
+	for i := 0; i < n; i++ {
+		path = strings.TrimLeft(path, "/")
+		if index := strings.IndexByte(path, '/'); index != -1 {
+			path = path[index:]
+		} else {
+			path = ""
+		}
+	}
+	prefix := joinMountPrefix(mountPrefix(r), original[:len(original)-len(path)])
+	path = "/" + strings.TrimLeft(path, "/")
+	tmp := r.WithContext(context.WithValue(r.Context(), mountPrefixKey{}, prefix))
+	tmp.URL = new(url.URL)
+	*tmp.URL = *r.URL
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		tmp.URL.Path = unescaped
+		tmp.URL.RawPath = ""
+		if unescaped != path {
+			tmp.URL.RawPath = path
+		}
+	} else {
+		tmp.URL.Path = path
+		tmp.URL.RawPath = ""
+	}
+	return tmp
+}
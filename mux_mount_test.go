@@ -0,0 +1,105 @@
+package slim
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+//新建一个被挂载的app，保留自己的中间件与错误处理
+func newMountedApp() *App {
+	sub := New(true)
+	sub.Use(func(ctx *Ctx, w *Response, r *Request) {
+		w.Header().Set("X-Sub", "1")
+		ctx.Next()
+	})
+	sub.SetErrorFunc(func(ctx *Ctx, err error) {
+		if err != nil {
+			_ = ctx.Response().Plain(http.StatusInternalServerError, "sub "+err.Error())
+		}
+	})
+	sub.Mux().Get("/", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "root")
+	}).SetName("index")
+	sub.Mux().Get("/users/:id", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "user "+r.Param("id")+" "+r.Raw().URL.Path)
+	}).SetName("user.show")
+	sub.Mux().Get("/fail", func(ctx *Ctx, w *Response, r *Request) error {
+		return errors.New("fail")
+	})
+	return sub
+}
+
+//测试挂载：移除前缀、路由组前缀、挂载点的根路径、保留被挂载的app的中间件与错误处理
+func TestMount(t *testing.T) {
+	app := New(true)
+	app.Use(func(ctx *Ctx, w *Response, r *Request) {
+		w.Header().Set("X-Parent", "1")
+		ctx.Next()
+	})
+	app.Mux().Group("/v1", func() {
+		app.Mux().Mount("/api/", newMountedApp())
+	})
+	for target, body := range map[string]string{
+		"/v1/api":             "root",
+		"/v1/api/":            "root",
+		"/v1/api/users/5":     "user 5 /users/5",
+		"/v1/api/users/a%20b": "user a b /users/a b",
+		"/v1/api/fail":        "sub fail",
+	} {
+		rec := serve(app, http.MethodGet, target)
+		if rec.Body.String() != body {
+			t.Fatal("TestMount fatal", target, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("X-Parent") != "1" || rec.Header().Get("X-Sub") != "1" {
+			t.Fatal("TestMount middleware fatal", target, rec.Header())
+		}
+	}
+	if rec := serve(app, http.MethodGet, "/v1/api/fail"); rec.Code != http.StatusInternalServerError {
+		t.Fatal("TestMount error func fatal", rec.Code)
+	}
+	for _, target := range []string{"/v1/apix", "/api/users/5"} {
+		if rec := serve(app, http.MethodGet, target); rec.Header().Get("X-Sub") != "" {
+			t.Fatal("TestMount prefix fatal", target, rec.Body.String())
+		}
+	}
+}
+
+//测试被挂载的app生成的url包含挂载的前缀
+func TestMountURL(t *testing.T) {
+	app := New(true)
+	sub := newMountedApp()
+	deep := newMountedApp()
+	tenant := newMountedApp()
+	app.Mux().Group("/v1", func() {
+		app.Mux().Mount("/api", sub)
+	})
+	sub.Mux().Mount("/deep", deep)
+	app.Mux().Mount("/t/:tenant", tenant)
+
+	for _, v := range []struct {
+		mux    *Mux
+		name   string
+		params map[string]string
+		result string
+	}{
+		{sub.Mux(), "user.show", map[string]string{"id": "5"}, "/v1/api/users/5"},
+		{sub.Mux(), "index", nil, "/v1/api"},
+		{deep.Mux(), "user.show", map[string]string{"id": "5"}, "/v1/api/deep/users/5"},
+		{tenant.Mux(), "user.show", map[string]string{"id": "5", "tenant": "acme"}, "/t/acme/users/5"},
+	} {
+		if result, err := v.mux.URL(v.name, v.params, nil); err != nil || result != v.result {
+			t.Fatal("TestMountURL fatal", v.name, result, err)
+		}
+		//生成的url可以被上级app匹配
+		if rec := serve(app, http.MethodGet, v.result); rec.Header().Get("X-Sub") != "1" {
+			t.Fatal("TestMountURL serve fatal", v.result, rec.Body.String())
+		}
+	}
+	if _, err := tenant.Mux().URL("user.show", map[string]string{"id": "5"}, nil); err == nil {
+		t.Fatal("TestMountURL missing param fatal")
+	}
+	if result, err := tenant.Mux().urlFunc("user.show", "tenant", "acme", "id", 5, "page", 2); err != nil || result != "/t/acme/users/5?page=2" {
+		t.Fatal("TestMountURL urlFunc fatal", result, err)
+	}
+}
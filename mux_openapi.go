@@ -0,0 +1,345 @@
+package slim
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//OpenAPI 3 文档
+type OpenAPI struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+}
+
+//OpenAPI 3支持的请求方法
+var openAPIMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPut:     true,
+	http.MethodPost:    true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	http.MethodHead:    true,
+	http.MethodPatch:   true,
+	http.MethodTrace:   true,
+}
+
+//生成OpenAPI 3文档
+//路径参数取自路由中的 :name 与 *name，参数约束取自路由的正则，标签取自路由的label，请求与响应的结构取自 SetSchema
+//文档不区分域名，不同域名下的同一个路径会合并到一起，CONNECT以及扩展的请求方法不会出现在文档中
+//挂载的 *slim.App 的路由会展开到挂载的路径下，挂载的其它http.Handler不会出现在文档中
+func (this *Mux) OpenAPI(title string, version string) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI:    "3.0.3",
+		Info:       OpenAPIInfo{Title: title, Version: version},
+		Paths:      map[string]map[string]*OpenAPIOperation{},
+		Components: OpenAPIComponents{Schemas: map[string]*OpenAPISchema{}},
+	}
+	builder := &openAPISchemaBuilder{schemas: doc.Components.Schemas, names: map[reflect.Type]string{}}
+	for _, route := range this.routes().routeMap {
+		if route.mount != nil {
+			if app, ok := route.mount.(*App); ok {
+				doc.merge(route, app.Mux().OpenAPI(title, version))
+			}
+			continue
+		}
+		path := openAPIPath(route)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = map[string]*OpenAPIOperation{}
+			doc.Paths[path] = item
+		}
+		for _, method := range route.methods {
+			if !openAPIMethods[method] {
+				continue
+			}
+			operation := &OpenAPIOperation{Tags: route.label, Responses: map[string]*OpenAPIResponse{}}
+			//自动生成的路由名称是数字，不适合作为operationId
+			if _, err := strconv.Atoi(route.name); err != nil {
+				operation.OperationID = route.name
+				if len(route.methods) > 1 {
+					operation.OperationID += "." + strings.ToLower(method)
+				}
+			}
+			for _, param := range route.params {
+				operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+					Name:     param.Name,
+					In:       "path",
+					Required: true,
+					Schema:   openAPIParamSchema(route, param.Name, param.Regexp),
+				})
+			}
+			if route.request != nil {
+				if method == http.MethodGet || method == http.MethodHead || method == http.MethodDelete || method == http.MethodOptions {
+					operation.Parameters = append(operation.Parameters, builder.query(route)...)
+				} else {
+					operation.RequestBody = &OpenAPIRequestBody{
+						Required: true,
+						Content:  map[string]OpenAPIMediaType{"application/json": {Schema: builder.build(route.request)}},
+					}
+				}
+			}
+			if route.response != nil {
+				operation.Responses["200"] = &OpenAPIResponse{
+					Description: "OK",
+					Content:     map[string]OpenAPIMediaType{"application/json": {Schema: builder.build(route.response)}},
+				}
+			} else {
+				operation.Responses["default"] = &OpenAPIResponse{Description: "default response"}
+			}
+			item[strings.ToLower(method)] = operation
+		}
+		if len(item) == 0 {
+			delete(doc.Paths, path)
+		}
+	}
+	return doc
+}
+
+//将挂载的 *slim.App 的文档合并到挂载的路径下，挂载前缀中的参数会添加到每个接口
+func (this *OpenAPI) merge(route *Route, sub *OpenAPI) {
+	prefix := strings.TrimSuffix(openAPIPath(route), "/{"+mountParam+"}")
+	var params []*OpenAPIParameter
+	for _, param := range route.params {
+		if param.Name != mountParam {
+			params = append(params, &OpenAPIParameter{Name: param.Name, In: "path", Required: true, Schema: openAPIParamSchema(route, param.Name, param.Regexp)})
+		}
+	}
+	for path, item := range sub.Paths {
+		if path == "/" && prefix != "" {
+			path = ""
+		}
+		for _, operation := range item {
+			operation.Parameters = append(append([]*OpenAPIParameter{}, params...), operation.Parameters...)
+		}
+		this.Paths[prefix+path] = item
+	}
+	for name, schema := range sub.Components.Schemas {
+		if _, ok := this.Components.Schemas[name]; !ok {
+			this.Components.Schemas[name] = schema
+		}
+	}
+}
+
+//将路由路径转为OpenAPI的路径，比如 /user/:id<int>/*path 转为 /user/{id}/{path}
+func openAPIPath(route *Route) string {
+	var path strings.Builder
+	var i int = 0
+	for _, v := range route.pattern {
+		if v != ':' && v != '*' {
+			path.WriteRune(v)
+			continue
+		}
+		path.WriteString("{" + route.params[i].Name + "}")
+		i++
+	}
+	return path.String()
+}
+
+//生成路径参数的结构，路由上设置的正则优先于路径中的参数约束
+func openAPIParamSchema(route *Route, name string, constraint *regexp.Regexp) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "string"}
+	if re, ok := route.regexp[name]; ok {
+		schema.Pattern = re.String()
+	} else if constraint != nil {
+		schema.Pattern = constraint.String()
+	}
+	return schema
+}
+
+//根据go的类型生成OpenAPI的结构，具名的结构体放入components中
+type openAPISchemaBuilder struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+var openAPITimeType = reflect.TypeOf(time.Time{})
+
+func (this *openAPISchemaBuilder) build(t reflect.Type) *OpenAPISchema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+	var schema *OpenAPISchema
+	switch t.Kind() {
+	case reflect.Bool:
+		schema = &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		schema = &OpenAPISchema{Type: "integer"}
+	case reflect.Int32, reflect.Uint32:
+		schema = &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		schema = &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		schema = &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		schema = &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		schema = &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			schema = &OpenAPISchema{Type: "string", Format: "byte"}
+		} else {
+			schema = &OpenAPISchema{Type: "array", Items: this.build(t.Elem())}
+		}
+	case reflect.Map:
+		schema = &OpenAPISchema{Type: "object", AdditionalProperties: this.build(t.Elem())}
+	case reflect.Struct:
+		if t == openAPITimeType {
+			schema = &OpenAPISchema{Type: "string", Format: "date-time"}
+		} else if t.Name() == "" {
+			schema = this.object(t)
+		} else {
+			schema = &OpenAPISchema{Ref: "#/components/schemas/" + this.ref(t)}
+		}
+	default:
+		schema = &OpenAPISchema{}
+	}
+	if nullable && schema.Ref == "" {
+		schema.Nullable = true
+	}
+	return schema
+}
+
+//注册具名结构体，先占位再生成，以支持递归引用的结构体
+func (this *openAPISchemaBuilder) ref(t reflect.Type) string {
+	if name, ok := this.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, ok := this.schemas[name]; ok {
+		name = strings.NewReplacer("/", "_", "[", "_", "]", "_", "*", "_", ",", "_", " ", "").Replace(t.PkgPath() + "." + t.Name())
+	}
+	this.names[t] = name
+	this.schemas[name] = nil
+	this.schemas[name] = this.object(t)
+	return name
+}
+
+func (this *openAPISchemaBuilder) object(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	this.fields(t, func(name string, field reflect.StructField, omitempty bool) {
+		schema.Properties[name] = this.build(field.Type)
+		if !omitempty && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}, "json")
+	sort.Strings(schema.Required)
+	return schema
+}
+
+//生成查询参数，GET等请求的请求数据通过查询字符串传递，字段名称取自schema标签
+func (this *openAPISchemaBuilder) query(route *Route) []*OpenAPIParameter {
+	t := route.request
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	params := make([]*OpenAPIParameter, 0, t.NumField())
+	this.fields(t, func(name string, field reflect.StructField, omitempty bool) {
+		for _, v := range route.params {
+			if v.Name == name {
+				return
+			}
+		}
+		params = append(params, &OpenAPIParameter{Name: name, In: "query", Required: false, Schema: this.build(field.Type)})
+	}, "schema")
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+	return params
+}
+
+//遍历结构体导出的字段，匿名嵌入的结构体会被展开
+func (this *openAPISchemaBuilder) fields(t reflect.Type, f func(name string, field reflect.StructField, omitempty bool), tagKey string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get(tagKey), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		if field.Anonymous && tag[0] == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				this.fields(ft, f, tagKey)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = field.Name
+		}
+		omitempty := false
+		for _, v := range tag[1:] {
+			if v == "omitempty" {
+				omitempty = true
+			}
+		}
+		f(name, field, omitempty)
+	}
+}
@@ -0,0 +1,96 @@
+package slim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type testOpenAPIUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+//新建一个带有挂载的app
+func newOpenAPIApp() *App {
+	handler := func(ctx *Ctx, w *Response, r *Request) error {
+		return nil
+	}
+	app := New(true)
+	app.Mux().Group("/api", func(g *RouteGroup) {
+		g.AddLabel("user")
+		app.Mux().Get("/users/:id<int>", handler).SetName("user.show").SetSchema(nil, testOpenAPIUser{})
+		app.Mux().Post("/users", handler).SetSchema(testOpenAPIUser{}, nil)
+	})
+	sub := New(true)
+	sub.Mux().Get("/", handler).SetName("sub.index")
+	sub.Mux().Get("/items/:item", handler).SetName("sub.item")
+	app.Mux().Mount("/sub/:tenant", sub)
+	app.Mux().Mount("/raw", http.NotFoundHandler())
+	return app
+}
+
+//测试生成OpenAPI文档，挂载的app展开到挂载的路径下
+func TestOpenAPI(t *testing.T) {
+	doc := newOpenAPIApp().Mux().OpenAPI("test", "1.0.0")
+	for path := range doc.Paths {
+		if strings.Contains(path, mountParam) || strings.HasPrefix(path, "/raw") {
+			t.Fatal("TestOpenAPI mount fatal", path)
+		}
+	}
+	if len(doc.Paths) != 4 {
+		t.Fatal("TestOpenAPI paths fatal", len(doc.Paths))
+	}
+	operation := doc.Paths["/api/users/{id}"]["get"]
+	if operation == nil || operation.OperationID != "user.show" || len(operation.Tags) != 1 || operation.Tags[0] != "user" {
+		t.Fatal("TestOpenAPI operation fatal", operation)
+	}
+	if len(operation.Parameters) != 1 || operation.Parameters[0].Name != "id" || operation.Parameters[0].Schema.Pattern == "" {
+		t.Fatal("TestOpenAPI param fatal", operation.Parameters)
+	}
+	if operation.Responses["200"].Content["application/json"].Schema.Ref == "" {
+		t.Fatal("TestOpenAPI response fatal")
+	}
+	if post := doc.Paths["/api/users"]["post"]; post == nil || post.RequestBody == nil {
+		t.Fatal("TestOpenAPI request body fatal")
+	}
+	if len(doc.Components.Schemas) != 1 {
+		t.Fatal("TestOpenAPI schemas fatal", doc.Components.Schemas)
+	}
+	//挂载前缀中的参数添加到挂载的app的接口中
+	operation = doc.Paths["/sub/{tenant}/items/{item}"]["get"]
+	if operation == nil || operation.OperationID != "sub.item" || len(operation.Parameters) != 2 ||
+		operation.Parameters[0].Name != "tenant" || operation.Parameters[1].Name != "item" {
+		t.Fatal("TestOpenAPI mount operation fatal", operation)
+	}
+	if doc.Paths["/sub/{tenant}"]["get"] == nil {
+		t.Fatal("TestOpenAPI mount root fatal", doc.Paths)
+	}
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//测试以json格式导出路由，不包含挂载的路由
+func TestDumpRouteMapJSON(t *testing.T) {
+	b, err := newOpenAPIApp().Mux().DumpRouteMapJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shadows []RouteShadow
+	if err := json.Unmarshal(b, &shadows); err != nil {
+		t.Fatal(err)
+	}
+	if len(shadows) != 2 {
+		t.Fatal("TestDumpRouteMapJSON fatal", string(b))
+	}
+	for _, v := range shadows {
+		if strings.Contains(v.Path, mountParam) {
+			t.Fatal("TestDumpRouteMapJSON mount fatal", v.Path)
+		}
+	}
+	if !strings.Contains(string(b), `"path": "/api/users/:id<int>"`) || !strings.Contains(string(b), `"name": "user.show"`) {
+		t.Fatal("TestDumpRouteMapJSON fatal", string(b))
+	}
+}
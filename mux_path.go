@@ -0,0 +1,65 @@
+package slim
+
+import (
+	"github.com/buexplain/go-slim/constant"
+	"net/url"
+	"strings"
+)
+
+//请求路径不规范时的处理策略
+//不规范的路径指的是：末尾带斜杠、包含连续的斜杠、包含 . 或 .. 段、与不区分大小写的路由大小写不一致
+type PathPolicy int
+
+const (
+	//按规范化后的路径匹配路由
+	PathLoose PathPolicy = iota
+	//重定向到规范化后的路径，GET与HEAD请求使用301，其它请求使用308
+	PathRedirect
+	//不规范的路径不匹配任何路由
+	PathStrict
+)
+
+//设置请求路径不规范时的处理策略，默认是 PathLoose
+func (this *Mux) SetPathPolicy(policy PathPolicy) *Mux {
+	this.pathPolicy = policy
+	return this
+}
+
+//根据路由返回请求路径的规范写法，不区分大小写的路由，其静态段以注册时的大小写为准
+func (this *Route) canonicalPath(path string) string {
+	pattern := strings.Split(this.pattern, "/")
+	segments := strings.Split(path, "/")
+	for k, v := range pattern {
+		if k >= len(segments) || strings.IndexByte(v, '*') != -1 {
+			break
+		}
+		if !this.strict && strings.IndexByte(v, ':') == -1 && strings.EqualFold(v, segments[k]) {
+			segments[k] = v
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+//检查请求路径是否规范，不规范则根据策略返回重定向路由或未命中路由时的处理路由
+func (this *Mux) checkPath(table *routeTable, host string, ctx *Ctx, route *Route) *Route {
+	if this.pathPolicy == PathLoose {
+		return route
+	}
+	canonical := route.canonicalPath(ctx.Path())
+	if canonical == ctx.rawPath {
+		return route
+	}
+	for _, v := range route.params {
+		ctx.r.param.Del(v.Name)
+	}
+	if this.pathPolicy == PathStrict {
+		return this.notFound(table, host, ctx.Path())
+	}
+	//被挂载的app需要补上被移除的挂载前缀，否则会重定向到挂载点之外
+	location := mountPrefix(ctx.r.r) + (&url.URL{Path: canonical}).EscapedPath()
+	if ctx.r.r.URL.RawQuery != "" {
+		location += "?" + ctx.r.r.URL.RawQuery
+	}
+	ctx.w.Header().Set(constant.HeaderLocation, location)
+	return this.redirectRoute
+}
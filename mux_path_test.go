@@ -0,0 +1,116 @@
+package slim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+//发起一个请求，返回响应
+func serve(handler http.Handler, method string, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+//新建一个只有 /Foo 与 /user/:id 两个路由的app
+func newPathApp(policy PathPolicy) *App {
+	app := New(true)
+	app.Mux().SetPathPolicy(policy)
+	app.Mux().Any("/Foo", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "foo")
+	})
+	app.Mux().Get("/user/:id", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "user "+r.Param("id"))
+	})
+	return app
+}
+
+//测试规范化后匹配路由
+func TestPathLoose(t *testing.T) {
+	app := newPathApp(PathLoose)
+	for _, target := range []string{"/Foo", "/Foo/", "//Foo", "/a/../Foo", "/./Foo", "/foo"} {
+		if rec := serve(app, http.MethodGet, target); rec.Code != http.StatusOK || rec.Body.String() != "foo" {
+			t.Fatal("TestPathLoose fatal", target, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := serve(app, http.MethodGet, "/user/Bob/"); rec.Body.String() != "user Bob" {
+		t.Fatal("TestPathLoose param fatal", rec.Body.String())
+	}
+}
+
+//测试重定向到规范路径
+func TestPathRedirect(t *testing.T) {
+	app := newPathApp(PathRedirect)
+	for target, location := range map[string]string{
+		"/Foo/":        "/Foo",
+		"//Foo":        "/Foo",
+		"/a/../Foo":    "/Foo",
+		"/foo":         "/Foo",
+		"/foo/?a=1":    "/Foo?a=1",
+		"/USER//Bob/":  "/user/Bob",
+		"/user/a%20b/": "/user/a%20b",
+	} {
+		rec := serve(app, http.MethodGet, target)
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != location {
+			t.Fatal("TestPathRedirect fatal", target, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+	if rec := serve(app, http.MethodPost, "/Foo/"); rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != "/Foo" {
+		t.Fatal("TestPathRedirect post fatal", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := serve(app, http.MethodGet, "/Foo"); rec.Code != http.StatusOK || rec.Body.String() != "foo" {
+		t.Fatal("TestPathRedirect canonical fatal", rec.Code, rec.Body.String())
+	}
+}
+
+//测试被挂载的app重定向时保留挂载前缀
+func TestPathRedirectMount(t *testing.T) {
+	app := New(true)
+	app.Mux().Mount("/sub", newPathApp(PathRedirect))
+	for target, location := range map[string]string{
+		"/sub/foo/":      "/sub/Foo",
+		"/sub/Foo//?a=1": "/sub/Foo?a=1",
+	} {
+		rec := serve(app, http.MethodGet, target)
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != location {
+			t.Fatal("TestPathRedirectMount fatal", target, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+	//挂载前缀中连续的斜杠不能生成 //host 形式的地址
+	if rec := serve(app, http.MethodGet, "//sub/foo"); rec.Header().Get("Location") != "/sub/Foo" {
+		t.Fatal("TestPathRedirectMount slash fatal", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+//测试不规范的路径不匹配任何路由
+func TestPathStrict(t *testing.T) {
+	app := newPathApp(PathStrict)
+	for _, target := range []string{"/Foo/", "//Foo", "/a/../Foo", "/foo", "/user/Bob/"} {
+		if rec := serve(app, http.MethodGet, target); rec.Body.String() == "foo" || rec.Body.String() == "user Bob" {
+			t.Fatal("TestPathStrict fatal", target, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := serve(app, http.MethodGet, "/user/Bob"); rec.Body.String() != "user Bob" {
+		t.Fatal("TestPathStrict canonical fatal", rec.Code, rec.Body.String())
+	}
+}
+
+//测试不规范的路径交给路由组未命中路由时的处理
+func TestPathStrictGroupDefault(t *testing.T) {
+	app := New(true)
+	mux := app.Mux().SetPathPolicy(PathStrict)
+	mux.Group("/api", func(g *RouteGroup) {
+		g.SetDefaultRoute(func(ctx *Ctx, w *Response, r *Request) error {
+			return w.Plain(http.StatusNotFound, "api not found")
+		})
+		mux.Get("/v1/x", func(ctx *Ctx, w *Response, r *Request) error {
+			return w.Plain(http.StatusOK, "x")
+		})
+	})
+	for _, target := range []string{"/api/v1/x/", "/api/v1//x", "/api/v1/y"} {
+		if rec := serve(app, http.MethodGet, target); rec.Code != http.StatusNotFound || rec.Body.String() != "api not found" {
+			t.Fatal("TestPathStrictGroupDefault fatal", target, rec.Code, rec.Body.String())
+		}
+	}
+}
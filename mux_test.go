@@ -0,0 +1,107 @@
+package slim
+
+import (
+	"github.com/buexplain/go-slim/constant"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//新建一个 /item 路由只有GET与DELETE请求方法的app
+func newMethodApp() *App {
+	app := New(true)
+	app.Mux().Get("/item", func(ctx *Ctx, w *Response, r *Request) error {
+		w.Header().Set(constant.HeaderContentLength, "4")
+		return w.Plain(http.StatusOK, "item")
+	})
+	app.Mux().Delete("/item", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "deleted")
+	})
+	return app
+}
+
+//测试请求路径存在但请求方法不匹配时响应405以及Allow
+func TestMethodNotAllowed(t *testing.T) {
+	app := newMethodApp()
+	rec := serveHeader(app, http.MethodPost, "/item", http.Header{"Accept": {"text/html"}})
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get(constant.HeaderAllow) != "DELETE, GET" {
+		t.Fatal("TestMethodNotAllowed fatal", rec.Code, rec.Header())
+	}
+	//请求路径在任何请求方法下都不存在，响应404，并且没有Allow
+	rec = serveHeader(app, http.MethodPost, "/none", http.Header{"Accept": {"text/html"}})
+	if rec.Code != http.StatusNotFound || rec.Header().Get(constant.HeaderAllow) != "" {
+		t.Fatal("TestMethodNotAllowed not found fatal", rec.Code, rec.Header())
+	}
+	//自定义405路由
+	app.Mux().SetMethodNotAllowedRoute(func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusMethodNotAllowed, "custom "+w.Header().Get(constant.HeaderAllow))
+	})
+	rec = serve(app, http.MethodPut, "/item")
+	if rec.Code != http.StatusMethodNotAllowed || rec.Body.String() != "custom DELETE, GET" {
+		t.Fatal("TestMethodNotAllowed custom fatal", rec.Code, rec.Body.String())
+	}
+	//未开启自动响应时，HEAD与OPTIONS同样响应405
+	for _, method := range []string{http.MethodHead, http.MethodOptions} {
+		if rec := serve(app, method, "/item"); rec.Code != http.StatusMethodNotAllowed {
+			t.Fatal("TestMethodNotAllowed fatal", method, rec.Code)
+		}
+	}
+}
+
+//测试用GET路由自动响应HEAD请求，丢弃body但保留Content-Length
+func TestAutoHead(t *testing.T) {
+	app := newMethodApp()
+	app.Mux().SetAutoHead(true)
+	app.Mux().Get("/plain", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "hello")
+	})
+	rec := serve(app, http.MethodHead, "/item")
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 || rec.Header().Get(constant.HeaderContentLength) != "4" {
+		t.Fatal("TestAutoHead fatal", rec.Code, rec.Body.String(), rec.Header())
+	}
+	//没有设置Content-Length时按body的长度设置
+	rec = serve(app, http.MethodHead, "/plain")
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 || rec.Header().Get(constant.HeaderContentLength) != "5" {
+		t.Fatal("TestAutoHead length fatal", rec.Code, rec.Body.String(), rec.Header())
+	}
+	//Allow包含HEAD
+	rec = serve(app, http.MethodPost, "/item")
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get(constant.HeaderAllow) != "DELETE, GET, HEAD" {
+		t.Fatal("TestAutoHead allow fatal", rec.Code, rec.Header())
+	}
+	//显式注册的HEAD路由优先
+	app.Mux().Head("/item", func(ctx *Ctx, w *Response, r *Request) error {
+		w.Header().Set("X-Head", "1")
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+	if rec := serve(app, http.MethodHead, "/item"); rec.Code != http.StatusNoContent || rec.Header().Get("X-Head") != "1" {
+		t.Fatal("TestAutoHead explicit fatal", rec.Code, rec.Header())
+	}
+}
+
+//测试自动响应OPTIONS请求
+func TestAutoOptions(t *testing.T) {
+	app := newMethodApp()
+	app.Mux().SetAutoOptions(true).SetAutoHead(true)
+	rec := serve(app, http.MethodOptions, "/item")
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 || rec.Header().Get(constant.HeaderAllow) != "DELETE, GET, HEAD, OPTIONS" {
+		t.Fatal("TestAutoOptions fatal", rec.Code, rec.Header())
+	}
+	//其它请求方法的405同样包含OPTIONS
+	rec = serve(app, http.MethodPut, "/item")
+	if rec.Code != http.StatusMethodNotAllowed || !strings.HasSuffix(rec.Header().Get(constant.HeaderAllow), "OPTIONS") {
+		t.Fatal("TestAutoOptions allow fatal", rec.Code, rec.Header())
+	}
+	//不存在的路径不自动响应
+	if rec := serveHeader(app, http.MethodOptions, "/none", http.Header{"Accept": {"text/html"}}); rec.Code != http.StatusNotFound {
+		t.Fatal("TestAutoOptions not found fatal", rec.Code)
+	}
+	//显式注册的OPTIONS路由优先
+	app.Mux().Options("/item", func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, "options")
+	})
+	if rec := serve(app, http.MethodOptions, "/item"); rec.Body.String() != "options" {
+		t.Fatal("TestAutoOptions explicit fatal", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,115 @@
+package slim
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+//根据路由名称与参数生成url，被挂载的 *slim.App 生成的url包含挂载的前缀
+func (this *Mux) URL(name string, params map[string]string, query url.Values) (string, error) {
+	route, ok := this.routes().routeMap[name]
+	if !ok {
+		return "", fmt.Errorf("route not found: %s", name)
+	}
+	result, err := this.url(route, params)
+	if err != nil {
+		return "", err
+	}
+	if this.mount != nil {
+		//挂载前缀中的参数同样从params中取值
+		tmp := make(map[string]string, len(params)+1)
+		for k, v := range params {
+			tmp[k] = v
+		}
+		tmp[mountParam] = ""
+		prefix, err := this.mount.mux.URL(this.mount.name, tmp, nil)
+		if err != nil {
+			return "", err
+		}
+		if result == "/" {
+			result = prefix
+		} else if prefix != "/" {
+			result = prefix + result
+		}
+	}
+	if len(query) > 0 {
+		result += "?" + query.Encode()
+	}
+	return result, nil
+}
+
+//根据路由与参数生成url的路径部分
+func (this *Mux) url(route *Route, params map[string]string) (string, error) {
+	name := route.name
+	var path strings.Builder
+	var i int = 0
+	for _, v := range route.pattern {
+		if v != ':' && v != '*' {
+			path.WriteRune(v)
+			continue
+		}
+		param := route.params[i]
+		i++
+		value, ok := params[param.Name]
+		if !ok || (value == "" && param.Kind == ':') {
+			return "", fmt.Errorf("route %s missing param: %s", name, param.Name)
+		}
+		if !param.Match(value) {
+			return "", fmt.Errorf("route %s param %s does not match constraint %s: %s", name, param.Name, param.Constraint, value)
+		}
+		if re, ok := route.regexp[param.Name]; ok && !re.MatchString(value) {
+			return "", fmt.Errorf("route %s param %s does not match regexp %s: %s", name, param.Name, re.String(), value)
+		}
+		if param.Kind == ':' {
+			path.WriteString(url.PathEscape(value))
+			continue
+		}
+		//通配参数保留路径分隔符
+		segments := strings.Split(strings.TrimLeft(value, "/"), "/")
+		for k, v := range segments {
+			segments[k] = url.PathEscape(v)
+		}
+		path.WriteString(strings.Join(segments, "/"))
+	}
+	result := path.String()
+	if len(result) > 1 {
+		result = strings.TrimRight(result, "/")
+	}
+	return result, nil
+}
+
+//生成url的模板函数，参数以键值对的形式传入，不属于路由的参数会作为查询参数，例如：{{URL "article.show" "id" 1 "page" 2}}
+func (this *Mux) urlFunc(name string, pairs ...interface{}) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("route %s params must be key-value pairs", name)
+	}
+	route, ok := this.routes().routeMap[name]
+	if !ok {
+		return "", fmt.Errorf("route not found: %s", name)
+	}
+	names := make(map[string]bool, len(route.params))
+	for _, v := range route.params {
+		names[v.Name] = true
+	}
+	//挂载前缀中的参数
+	for mux := this; mux.mount != nil; mux = mux.mount.mux {
+		for _, v := range mux.mount.params {
+			if v.Name != mountParam {
+				names[v.Name] = true
+			}
+		}
+	}
+	params := make(map[string]string, len(pairs)/2)
+	query := url.Values{}
+	for i := 0; i < len(pairs); i += 2 {
+		key := fmt.Sprint(pairs[i])
+		value := fmt.Sprint(pairs[i+1])
+		if names[key] {
+			params[key] = value
+		} else {
+			query.Add(key, value)
+		}
+	}
+	return this.URL(name, params, query)
+}
@@ -0,0 +1,114 @@
+package slim
+
+import (
+	"github.com/buexplain/go-slim/view"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//新建一个带有具名路由的mux
+func newURLMux() *Mux {
+	handler := func(ctx *Ctx, w *Response, r *Request) error {
+		return nil
+	}
+	mux := NewMux()
+	mux.Get("/", handler).SetName("home")
+	mux.Get("/article/:id<int>", handler).SetName("article.show")
+	mux.Get("/user/:name", handler).SetName("user.show").Regexp("name", "^[a-z]+$")
+	mux.Get("/static/*path", handler).SetName("static")
+	return mux
+}
+
+//测试根据路由名称生成url
+func TestURL(t *testing.T) {
+	mux := newURLMux()
+	for _, v := range []struct {
+		name   string
+		params map[string]string
+		query  url.Values
+		result string
+	}{
+		{"home", nil, nil, "/"},
+		{"home", nil, url.Values{"a": {"1 2"}}, "/?a=1+2"},
+		{"article.show", map[string]string{"id": "10"}, url.Values{"page": {"2"}}, "/article/10?page=2"},
+		{"user.show", map[string]string{"name": "bob"}, nil, "/user/bob"},
+		{"static", map[string]string{"path": "css/a b.css"}, nil, "/static/css/a%20b.css"},
+		{"static", map[string]string{"path": "/js/?.js"}, nil, "/static/js/%3F.js"},
+		{"static", map[string]string{"path": ""}, nil, "/static"},
+	} {
+		if result, err := mux.URL(v.name, v.params, v.query); err != nil || result != v.result {
+			t.Fatal("TestURL fatal", v.name, result, err)
+		}
+	}
+	for _, v := range []struct {
+		name   string
+		params map[string]string
+	}{
+		{"undefined", nil},
+		{"article.show", nil},
+		{"article.show", map[string]string{"id": ""}},
+		{"article.show", map[string]string{"id": "abc"}},
+		{"user.show", map[string]string{"name": "Bob"}},
+		{"static", nil},
+	} {
+		if result, err := mux.URL(v.name, v.params, nil); err == nil {
+			t.Fatal("TestURL error fatal", v.name, v.params, result)
+		}
+	}
+}
+
+//测试模板函数，不属于路由的参数作为查询参数
+func TestURLFunc(t *testing.T) {
+	mux := newURLMux()
+	if result, err := mux.urlFunc("article.show", "id", 1, "page", 2); err != nil || result != "/article/1?page=2" {
+		t.Fatal("TestURLFunc fatal", result, err)
+	}
+	if _, err := mux.urlFunc("article.show", "id"); err == nil {
+		t.Fatal("TestURLFunc pairs fatal")
+	}
+	if _, err := mux.urlFunc("undefined"); err == nil {
+		t.Fatal("TestURLFunc undefined fatal")
+	}
+}
+
+//测试模板中的URL函数绑定到各自的app，以及设置空的view
+func TestURLView(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "url.html"), []byte(`{{URL "user.show" "id" 5}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	shared := view.New(dir, true)
+	newApp := func(path string) *App {
+		app := New(true)
+		app.SetView(shared)
+		app.Mux().Get(path, func(ctx *Ctx, w *Response, r *Request) error {
+			return w.View(http.StatusOK, "url.html")
+		}).SetName("user.show")
+		return app
+	}
+	a := newApp("/a/users/:id")
+	b := newApp("/b/users/:id")
+	for i := 0; i < 2; i++ {
+		if rec := serve(a, http.MethodGet, "/a/users/1"); strings.TrimSpace(rec.Body.String()) != "/a/users/5" {
+			t.Fatal("TestURLView fatal", rec.Body.String())
+		}
+		if rec := serve(b, http.MethodGet, "/b/users/1"); strings.TrimSpace(rec.Body.String()) != "/b/users/5" {
+			t.Fatal("TestURLView fatal", rec.Body.String())
+		}
+	}
+
+	app := New(true)
+	app.SetView(nil)
+	if app.View() != nil {
+		t.Fatal("TestURLView nil fatal")
+	}
+}
@@ -0,0 +1,43 @@
+package slim
+
+import (
+	"github.com/buexplain/go-slim/errors"
+	"github.com/buexplain/go-slim/websocket"
+	"io"
+)
+
+//WebSocket处理函数，返回后连接会被关闭
+type WebSocketHandler func(ctx *Ctx, conn *websocket.Conn) error
+
+//添加一个WebSocket路由，全局中间件、路由中间件以及session会在升级之前正常执行
+//握手失败作为客户端错误处理，升级之后的错误无法再响应给客户端，只会发送1011关闭帧，并交给错误处理记录
+//客户端正常关闭连接产生的 *websocket.CloseError 以及直接断开连接产生的 io.EOF、io.ErrUnexpectedEOF 不会被视为错误
+//upgrader为空则使用 websocket.DefaultUpgrader
+func (this *Mux) WebSocket(path string, handler WebSocketHandler, upgrader ...*websocket.Upgrader) RouteSetInterface {
+	u := websocket.DefaultUpgrader
+	if len(upgrader) > 0 && upgrader[0] != nil {
+		u = upgrader[0]
+	}
+	return this.Get(path, func(ctx *Ctx, w *Response, r *Request) error {
+		conn, err := u.Upgrade(w, r.Raw(), nil)
+		if err != nil {
+			if _, ok := err.(websocket.HandshakeError); ok {
+				return errors.MarkClient(err)
+			}
+			return err
+		}
+		err = handler(ctx, conn)
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+			err = nil
+		} else if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			//客户端没有发送关闭帧就断开了连接，同样视为正常结束
+			err = nil
+		}
+		if err != nil {
+			_ = conn.Close(websocket.CloseInternalServerErr, "")
+			return err
+		}
+		_ = conn.Close(websocket.CloseNormalClosure, "")
+		return nil
+	})
+}
@@ -0,0 +1,112 @@
+package slim
+
+import (
+	"bufio"
+	"github.com/buexplain/go-slim/websocket"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+//测试WebSocket路由在升级之前执行中间件与session，客户端直接断开连接不视为错误
+func TestWebSocket(t *testing.T) {
+	app := New(true)
+	sessionHandler := &testSessionHandler{}
+	app.SetSessionHandler(sessionHandler)
+	app.Use(func(ctx *Ctx, w *Response, r *Request) {
+		w.Header().Set("X-Middleware", "1")
+		r.Session().Set("user", "bob")
+		ctx.Next()
+	})
+	result := make(chan error, 1)
+	app.Mux().WebSocket("/ws", func(ctx *Ctx, conn *websocket.Conn) error {
+		for {
+			messageType, p, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			if err := conn.WriteMessage(messageType, p); err != nil {
+				return err
+			}
+		}
+	}).SetErrorFunc(func(ctx *Ctx, err error) {
+		result <- err
+	})
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	_, _ = io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: "+host+"\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	res, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols || res.Header.Get("X-Middleware") != "1" {
+		t.Fatal("TestWebSocket handshake fatal", res.Status, res.Header)
+	}
+	if cookies := res.Cookies(); len(cookies) != 1 || cookies[0].Name != "session" {
+		t.Fatal("TestWebSocket session cookie fatal", res.Header)
+	}
+
+	//发送一个带掩码的文本帧，读取回显
+	payload := []byte("hi")
+	mask := [4]byte{1, 2, 3, 4}
+	frame := append([]byte{0x80 | websocket.TextMessage, 0x80 | byte(len(payload))}, mask[:]...)
+	for i, v := range payload {
+		frame = append(frame, v^mask[i%4])
+	}
+	_, _ = conn.Write(frame)
+	echo := make([]byte, 2+len(payload))
+	if _, err := io.ReadFull(br, echo); err != nil {
+		t.Fatal(err)
+	}
+	if int(echo[0]&0x0f) != websocket.TextMessage || string(echo[2:]) != "hi" {
+		t.Fatal("TestWebSocket echo fatal", echo)
+	}
+
+	//不发送关闭帧直接断开连接
+	_ = conn.Close()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal("TestWebSocket close fatal", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("TestWebSocket close timeout")
+	}
+	if sessionHandler.saved != 1 {
+		t.Fatal("TestWebSocket session saved fatal", sessionHandler.saved)
+	}
+}
+
+//测试握手失败作为客户端错误响应
+func TestWebSocketHandshakeError(t *testing.T) {
+	app := New(true)
+	app.Mux().WebSocket("/ws", func(ctx *Ctx, conn *websocket.Conn) error {
+		t.Error("TestWebSocketHandshakeError fatal, handler should not be called")
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatal("TestWebSocketHandshakeError fatal", rec.Code, rec.Body.String())
+	}
+	//非浏览器请求响应json，错误码为400
+	if rec := serve(app, http.MethodGet, "/ws"); !strings.Contains(rec.Body.String(), "400") {
+		t.Fatal("TestWebSocketHandshakeError json fatal", rec.Code, rec.Body.String())
+	}
+}
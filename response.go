@@ -1,6 +1,7 @@
 package slim
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"encoding/xml"
@@ -10,6 +11,7 @@ import (
 	"github.com/buexplain/go-slim/tsmap"
 	"html/template"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -24,6 +26,10 @@ type Response struct {
 	store      *tsmap.TSMap
 	statusCode int
 	buffer     *bytes.Buffer
+	//是否处于流式响应模式
+	stream bool
+	//底层的连接是否已经被劫持
+	hijacked bool
 }
 
 func NewResponse(ctx *Ctx, w http.ResponseWriter) *Response {
@@ -49,9 +55,15 @@ func (this *Response) release() {
 	this.statusCode = 0
 	this.store.Release()
 	this.buffer.Reset()
+	this.stream = false
+	this.hijacked = false
 }
 
 func (this *Response) send() bool {
+	if this.stream || this.hijacked {
+		//流式响应已经提交了header与body，被劫持的连接由劫持者负责响应
+		return true
+	}
 	if this.statusCode != 0 {
 		//先写header
 		if this.ctx.r.session != nil {
@@ -63,6 +75,14 @@ func (this *Response) send() bool {
 				return false
 			}
 		}
+		//HEAD请求不写body，只告知body的长度
+		if this.ctx.r.r.Method == http.MethodHead {
+			if this.buffer.Len() > 0 && this.w.Header().Get(constant.HeaderContentLength) == "" {
+				this.w.Header().Set(constant.HeaderContentLength, strconv.Itoa(this.buffer.Len()))
+			}
+			this.w.WriteHeader(this.statusCode)
+			return true
+		}
 		//再写code
 		this.w.WriteHeader(this.statusCode)
 		//最后写body
@@ -73,6 +93,91 @@ func (this *Response) send() bool {
 	return true
 }
 
+//进入流式响应模式，立即提交session、header与状态码，并将已经缓冲的内容写入底层的http.ResponseWriter
+//之后的 Write 不再经过响应缓冲，直接写入底层的http.ResponseWriter，WriteHeader 不再生效
+//进入流式响应模式后，错误无法再响应给客户端，默认的错误处理只会记录日志
+func (this *Response) Stream(statusCode int) error {
+	if this.stream {
+		return nil
+	}
+	if this.hijacked {
+		return http.ErrHijacked
+	}
+	if this.ctx.r.session != nil {
+		if err := this.ctx.r.session.Save(this.ctx.r.r, this); err != nil {
+			//将session设置为nil，避免缓冲模式下的send再次保存
+			this.ctx.r.session = nil
+			return fmt.Errorf("save session error: %w", err)
+		}
+	}
+	this.stream = true
+	this.statusCode = statusCode
+	if parent, ok := this.w.(*Response); ok {
+		//被挂载的app，底层是上级app的响应，上级也要进入流式响应模式，否则内容会停留在上级的响应缓冲中
+		if err := parent.Stream(statusCode); err != nil {
+			return err
+		}
+	} else {
+		this.w.WriteHeader(statusCode)
+	}
+	if this.buffer.Len() > 0 {
+		if _, err := this.buffer.WriteTo(this.w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//判断是否处于流式响应模式
+func (this *Response) Streaming() bool {
+	return this.stream
+}
+
+//将流式响应已经写入的内容推送给客户端，底层的http.ResponseWriter不支持时不做任何处理
+//缓冲模式下调用无效，内容会在处理完毕后统一发送
+func (this *Response) Flush() {
+	if !this.stream {
+		return
+	}
+	if f, ok := this.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+//劫持底层的连接，用于WebSocket等协议升级，劫持前会先保存session，header需要劫持者自行写入连接
+//劫持后响应缓冲中的内容会被丢弃，错误无法再响应给客户端，默认的错误处理只会记录日志
+func (this *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if this.hijacked {
+		return nil, nil, http.ErrHijacked
+	}
+	if this.stream {
+		return nil, nil, fmt.Errorf("response already streaming")
+	}
+	h, ok := this.w.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("http.Hijacker not supported by %T", this.w)
+	}
+	if this.ctx.r.session != nil {
+		if err := this.ctx.r.session.Save(this.ctx.r.r, this); err != nil {
+			this.ctx.r.session = nil
+			return nil, nil, fmt.Errorf("save session error: %w", err)
+		}
+		this.ctx.r.session = nil
+	}
+	conn, brw, err := h.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	this.hijacked = true
+	this.buffer.Reset()
+	return conn, brw, nil
+}
+
+//判断底层的连接是否已经被劫持
+func (this *Response) Hijacked() bool {
+	return this.hijacked
+}
+
 func (this *Response) Raw() http.ResponseWriter {
 	return this.w
 }
@@ -82,6 +187,9 @@ func (this *Response) Header() http.Header {
 }
 
 func (this *Response) Write(b []byte) (int, error) {
+	if this.stream {
+		return this.w.Write(b)
+	}
 	i, err := this.buffer.Write(b)
 	if err != nil {
 		return i, err
@@ -90,6 +198,9 @@ func (this *Response) Write(b []byte) (int, error) {
 }
 
 func (this *Response) WriteHeader(statusCode int) {
+	if this.stream {
+		return
+	}
 	this.statusCode = statusCode
 }
 
@@ -126,7 +237,7 @@ func (this *Response) HTML(statusCode int, text string) error {
 }
 
 func (this *Response) Render(wr io.Writer, data interface{}, tpl string) error {
-	return this.ctx.app.view.Render(wr, tpl, data)
+	return this.ctx.app.view.Render(wr, tpl, data, this.ctx.app.viewFuncs())
 }
 
 func (this *Response) Store() *tsmap.TSMap {
@@ -174,7 +285,7 @@ func (this *Response) View(statusCode int, tpl string) error {
 		panic("view template not allow empty")
 	}
 	buff := &bytes.Buffer{}
-	err := this.ctx.app.view.Render(buff, tpl, this.store.Pop())
+	err := this.ctx.app.view.Render(buff, tpl, this.store.Pop(), this.ctx.app.viewFuncs())
 	if err != nil {
 		return err
 	}
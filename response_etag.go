@@ -0,0 +1,132 @@
+package slim
+
+import (
+	"github.com/buexplain/go-slim/constant"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//设置ETag，没有双引号的会自动加上，weak为true则是弱校验的ETag
+func (this *Response) SetETag(etag string, weak ...bool) *Response {
+	if !strings.HasPrefix(etag, "W/") && !strings.HasPrefix(etag, `"`) {
+		etag = `"` + etag + `"`
+	}
+	if len(weak) > 0 && weak[0] && !strings.HasPrefix(etag, "W/") {
+		etag = "W/" + etag
+	}
+	this.w.Header().Set(constant.HeaderETag, etag)
+	return this
+}
+
+//设置最后修改时间
+func (this *Response) SetLastModified(t time.Time) *Response {
+	if !t.IsZero() {
+		this.w.Header().Set(constant.HeaderLastModified, t.UTC().Format(http.TimeFormat))
+	}
+	return this
+}
+
+//根据已经设置的ETag与最后修改时间，按RFC 7232的顺序校验请求的前提条件
+//If-Match、If-Unmodified-Since不满足则响应412，If-None-Match、If-Modified-Since不满足则GET、HEAD请求响应304，其它请求响应412
+//返回true表示已经响应了304或者是412，处理函数应该直接返回，对于会修改资源的请求，应该在修改资源之前调用
+func (this *Response) CheckPreconditions() bool {
+	if this.stream || this.hijacked {
+		return false
+	}
+	req := this.ctx.r.r
+	etag := this.w.Header().Get(constant.HeaderETag)
+	lastModified, _ := http.ParseTime(this.w.Header().Get(constant.HeaderLastModified))
+	if im := req.Header.Get(constant.HeaderIfMatch); im != "" {
+		if !etagMatch(im, etag, false) {
+			this.precondition(http.StatusPreconditionFailed)
+			return true
+		}
+	} else if ius, err := http.ParseTime(req.Header.Get(constant.HeaderIfUnmodifiedSince)); err == nil && !lastModified.IsZero() {
+		if lastModified.Truncate(time.Second).After(ius) {
+			this.precondition(http.StatusPreconditionFailed)
+			return true
+		}
+	}
+	isGetOrHead := req.Method == http.MethodGet || req.Method == http.MethodHead
+	if inm := req.Header.Get(constant.HeaderIfNoneMatch); inm != "" {
+		if etagMatch(inm, etag, true) {
+			if isGetOrHead {
+				this.precondition(http.StatusNotModified)
+			} else {
+				this.precondition(http.StatusPreconditionFailed)
+			}
+			return true
+		}
+	} else if ims, err := http.ParseTime(req.Header.Get(constant.HeaderIfModifiedSince)); err == nil && isGetOrHead && !lastModified.IsZero() {
+		if !lastModified.Truncate(time.Second).After(ims) {
+			this.precondition(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+//丢弃已经写入的内容，只响应状态码
+func (this *Response) precondition(statusCode int) {
+	this.buffer.Reset()
+	header := this.w.Header()
+	header.Del(constant.HeaderContentType)
+	header.Del(constant.HeaderContentLength)
+	if statusCode == http.StatusPreconditionFailed {
+		header.Del(constant.HeaderETag)
+		header.Del(constant.HeaderLastModified)
+	}
+	this.WriteHeader(statusCode)
+}
+
+//判断If-Match或者是If-None-Match中的ETag列表是否匹配，weak为true则使用弱比较
+func etagMatch(list string, etag string, weak bool) bool {
+	for _, v := range parseETags(list) {
+		//* 匹配任何存在的资源
+		if v == "*" {
+			return true
+		}
+		if etag == "" {
+			continue
+		}
+		if weak {
+			if strings.TrimPrefix(v, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if !strings.HasPrefix(v, "W/") && !strings.HasPrefix(etag, "W/") && v == etag {
+			return true
+		}
+	}
+	return false
+}
+
+//解析以逗号分隔的ETag列表，ETag中可以包含逗号，遇到不合法的内容则停止解析
+func parseETags(s string) []string {
+	var etags []string
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return etags
+		}
+		if s[0] == '*' {
+			etags = append(etags, "*")
+			s = s[1:]
+			continue
+		}
+		start := 0
+		if strings.HasPrefix(s, "W/") {
+			start = 2
+		}
+		if len(s) <= start || s[start] != '"' {
+			return etags
+		}
+		end := strings.IndexByte(s[start+1:], '"')
+		if end == -1 {
+			return etags
+		}
+		end += start + 2
+		etags = append(etags, s[:end])
+		s = s[end:]
+	}
+}
@@ -0,0 +1,132 @@
+package slim
+
+import (
+	"github.com/buexplain/go-slim/constant"
+	"net/http"
+	"testing"
+	"time"
+)
+
+//资源的最后修改时间
+var etagModified = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+//新建一个校验前提条件的app，资源的ETag为etag，前提条件满足时响应 ok
+func newETagApp(etag string, modified *int) *App {
+	app := New(true)
+	app.Mux().Any("/", func(ctx *Ctx, w *Response, r *Request) error {
+		w.SetETag(etag).SetLastModified(etagModified)
+		if w.CheckPreconditions() {
+			return nil
+		}
+		if r.Raw().Method != http.MethodGet && modified != nil {
+			*modified++
+		}
+		return w.Plain(http.StatusOK, compressBody)
+	})
+	return app
+}
+
+//前提条件的测试用例
+type preconditionCase struct {
+	method string
+	header http.Header
+	code   int
+}
+
+func checkPreconditionCases(t *testing.T, app *App, cases []preconditionCase) {
+	for _, v := range cases {
+		rec := serveHeader(app, v.method, "/", v.header)
+		if rec.Code != v.code {
+			t.Fatal("CheckPreconditions fatal", v.method, v.header, rec.Code)
+		}
+		if v.code != http.StatusOK && rec.Body.Len() != 0 {
+			t.Fatal("CheckPreconditions body fatal", v.method, v.header, rec.Body.String())
+		}
+	}
+}
+
+//测试 If-Match 使用强比较，If-None-Match 使用弱比较，以及 * 与ETag列表
+func TestCheckPreconditionsETag(t *testing.T) {
+	checkPreconditionCases(t, newETagApp("abc", nil), []preconditionCase{
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {`"abc"`}}, http.StatusOK},
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {`"x", "abc"`}}, http.StatusOK},
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {`W/"abc"`}}, http.StatusPreconditionFailed},
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {`"x"`}}, http.StatusPreconditionFailed},
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {"*"}}, http.StatusOK},
+		{http.MethodGet, http.Header{constant.HeaderIfNoneMatch: {`"abc"`}}, http.StatusNotModified},
+		{http.MethodGet, http.Header{constant.HeaderIfNoneMatch: {`W/"abc"`}}, http.StatusNotModified},
+		{http.MethodHead, http.Header{constant.HeaderIfNoneMatch: {`"x", W/"abc"`}}, http.StatusNotModified},
+		{http.MethodGet, http.Header{constant.HeaderIfNoneMatch: {`"x"`}}, http.StatusOK},
+		{http.MethodGet, http.Header{constant.HeaderIfNoneMatch: {"*"}}, http.StatusNotModified},
+		{http.MethodPut, http.Header{constant.HeaderIfNoneMatch: {"*"}}, http.StatusPreconditionFailed},
+		{http.MethodPost, http.Header{constant.HeaderIfNoneMatch: {`W/"abc"`}}, http.StatusPreconditionFailed},
+	})
+	//弱校验的ETag不能通过 If-Match 的强比较
+	checkPreconditionCases(t, newETagApp(`W/"abc"`, nil), []preconditionCase{
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {`W/"abc"`}}, http.StatusPreconditionFailed},
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {`"abc"`}}, http.StatusPreconditionFailed},
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {"*"}}, http.StatusOK},
+		{http.MethodGet, http.Header{constant.HeaderIfNoneMatch: {`"abc"`}}, http.StatusNotModified},
+	})
+}
+
+//测试时间条件，以及ETag条件优先于时间条件
+func TestCheckPreconditionsTime(t *testing.T) {
+	before := etagModified.Add(-time.Hour).Format(http.TimeFormat)
+	same := etagModified.Format(http.TimeFormat)
+	after := etagModified.Add(time.Hour).Format(http.TimeFormat)
+	checkPreconditionCases(t, newETagApp("abc", nil), []preconditionCase{
+		{http.MethodGet, http.Header{constant.HeaderIfUnmodifiedSince: {before}}, http.StatusPreconditionFailed},
+		{http.MethodGet, http.Header{constant.HeaderIfUnmodifiedSince: {same}}, http.StatusOK},
+		{http.MethodGet, http.Header{constant.HeaderIfModifiedSince: {before}}, http.StatusOK},
+		{http.MethodGet, http.Header{constant.HeaderIfModifiedSince: {same}}, http.StatusNotModified},
+		{http.MethodGet, http.Header{constant.HeaderIfModifiedSince: {after}}, http.StatusNotModified},
+		//If-Modified-Since 只对GET、HEAD请求生效
+		{http.MethodPut, http.Header{constant.HeaderIfModifiedSince: {after}}, http.StatusOK},
+		//存在 If-Match 时忽略 If-Unmodified-Since
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {`"abc"`}, constant.HeaderIfUnmodifiedSince: {before}}, http.StatusOK},
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {`"x"`}, constant.HeaderIfUnmodifiedSince: {after}}, http.StatusPreconditionFailed},
+		//存在 If-None-Match 时忽略 If-Modified-Since
+		{http.MethodGet, http.Header{constant.HeaderIfNoneMatch: {`"x"`}, constant.HeaderIfModifiedSince: {after}}, http.StatusOK},
+		{http.MethodGet, http.Header{constant.HeaderIfNoneMatch: {`"abc"`}, constant.HeaderIfModifiedSince: {before}}, http.StatusNotModified},
+		//If-Match 不满足时不再校验 If-None-Match
+		{http.MethodGet, http.Header{constant.HeaderIfMatch: {`"x"`}, constant.HeaderIfNoneMatch: {`"x"`}}, http.StatusPreconditionFailed},
+	})
+}
+
+//测试修改资源的请求携带过期的 If-Match 时响应412，资源不会被修改
+func TestCheckPreconditionsPut(t *testing.T) {
+	var modified int
+	app := newETagApp("v2", &modified)
+	rec := serveHeader(app, http.MethodPut, "/", http.Header{constant.HeaderIfMatch: {`"v1"`}})
+	if rec.Code != http.StatusPreconditionFailed || modified != 0 {
+		t.Fatal("TestCheckPreconditionsPut fatal", rec.Code, modified)
+	}
+	if rec.Header().Get(constant.HeaderETag) != "" || rec.Header().Get(constant.HeaderLastModified) != "" {
+		t.Fatal("TestCheckPreconditionsPut header fatal", rec.Header())
+	}
+	rec = serveHeader(app, http.MethodPut, "/", http.Header{constant.HeaderIfMatch: {`"v2"`}})
+	if rec.Code != http.StatusOK || modified != 1 {
+		t.Fatal("TestCheckPreconditionsPut fatal", rec.Code, modified)
+	}
+}
+
+//测试与压缩中间件一起使用时，压缩后的弱校验ETag仍然可以得到304
+func TestCheckPreconditionsCompress(t *testing.T) {
+	app := newETagApp("abc", nil)
+	app.Use(Compress(0))
+	rec := serveHeader(app, http.MethodGet, "/", http.Header{constant.HeaderAcceptEncoding: {"gzip"}})
+	etag := rec.Header().Get(constant.HeaderETag)
+	if rec.Code != http.StatusOK || rec.Header().Get(constant.HeaderContentEncoding) != "gzip" || etag != `W/"abc"` {
+		t.Fatal("TestCheckPreconditionsCompress fatal", rec.Code, rec.Header())
+	}
+	rec = serveHeader(app, http.MethodGet, "/", http.Header{constant.HeaderAcceptEncoding: {"gzip"}, constant.HeaderIfNoneMatch: {etag}})
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 || rec.Header().Get(constant.HeaderContentEncoding) != "" {
+		t.Fatal("TestCheckPreconditionsCompress 304 fatal", rec.Code, rec.Header())
+	}
+	//弱校验的ETag不能用于 If-Match
+	rec = serveHeader(app, http.MethodPut, "/", http.Header{constant.HeaderAcceptEncoding: {"gzip"}, constant.HeaderIfMatch: {etag}})
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatal("TestCheckPreconditionsCompress 412 fatal", rec.Code)
+	}
+}
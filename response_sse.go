@@ -0,0 +1,140 @@
+package slim
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/buexplain/go-slim/constant"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//服务端推送的事件
+type SSEEvent struct {
+	//事件id，客户端重连时通过 Last-Event-ID 请求头带回
+	ID string
+	//事件类型，为空则是message
+	Event string
+	//事件数据，string与[]byte原样推送，其它类型转为json推送
+	Data interface{}
+	//客户端断开后的重连间隔，为0则不设置
+	Retry time.Duration
+}
+
+//默认的心跳间隔
+const SSEHeartbeat = 15 * time.Second
+
+//返回客户端重连时带回的最后一个事件id
+func (this *Request) LastEventID() string {
+	return this.r.Header.Get(constant.HeaderLastEventID)
+}
+
+//进入服务端推送事件模式，从events中读取事件并推送给客户端，每推送一个事件都会立即发送
+//每隔heartbeat推送一个注释保持连接，默认为 SSEHeartbeat，小于等于0则不推送
+//events被关闭、客户端断开连接或者是请求的context.Context结束后返回，推送失败返回错误
+func (this *Response) SSE(events <-chan *SSEEvent, heartbeat ...time.Duration) error {
+	if err := this.startSSE(); err != nil {
+		return err
+	}
+	d := SSEHeartbeat
+	if len(heartbeat) > 0 {
+		d = heartbeat[0]
+	}
+	var tick <-chan time.Time
+	if d > 0 {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	done := this.ctx.Done()
+	for {
+		select {
+		case <-done:
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := this.SendEvent(e); err != nil {
+				return err
+			}
+		case <-tick:
+			if _, err := this.Write([]byte(":\n\n")); err != nil {
+				return err
+			}
+			this.Flush()
+		}
+	}
+}
+
+//推送一个事件，未进入服务端推送事件模式则先进入
+func (this *Response) SendEvent(e *SSEEvent) error {
+	if err := this.startSSE(); err != nil {
+		return err
+	}
+	buff := &bytes.Buffer{}
+	if e.ID != "" {
+		buff.WriteString("id: ")
+		buff.WriteString(sseField(e.ID))
+		buff.WriteByte('\n')
+	}
+	if e.Event != "" {
+		buff.WriteString("event: ")
+		buff.WriteString(sseField(e.Event))
+		buff.WriteByte('\n')
+	}
+	if e.Retry > 0 {
+		buff.WriteString("retry: ")
+		buff.WriteString(strconv.FormatInt(int64(e.Retry/time.Millisecond), 10))
+		buff.WriteByte('\n')
+	}
+	var data string
+	switch v := e.Data.(type) {
+	case nil:
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		content, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data = string(content)
+	}
+	//多行数据，每行一个data字段
+	for _, line := range strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(data), "\n") {
+		buff.WriteString("data: ")
+		buff.WriteString(line)
+		buff.WriteByte('\n')
+	}
+	buff.WriteByte('\n')
+	if _, err := this.Write(buff.Bytes()); err != nil {
+		return err
+	}
+	this.Flush()
+	return nil
+}
+
+//设置服务端推送事件的header，并进入流式响应模式
+func (this *Response) startSSE() error {
+	if this.stream {
+		return nil
+	}
+	this.buffer.Reset()
+	this.w.Header().Set(constant.HeaderContentType, constant.MIMETextEventStream)
+	this.w.Header().Set(constant.HeaderCacheControl, "no-cache")
+	this.w.Header().Set(constant.HeaderXAccelBuffering, "no")
+	this.w.Header().Del(constant.HeaderContentLength)
+	if err := this.Stream(http.StatusOK); err != nil {
+		return err
+	}
+	this.Flush()
+	return nil
+}
+
+//id与event字段不能换行
+func sseField(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
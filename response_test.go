@@ -0,0 +1,76 @@
+package slim
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+//测试流式响应在处理函数返回之前就能送达客户端，包括被挂载的app
+func TestStream(t *testing.T) {
+	for _, mounted := range []bool{false, true} {
+		release := make(chan struct{})
+		var once sync.Once
+		//失败时也要放行处理函数，否则关闭测试服务器时会一直等待
+		defer once.Do(func() { close(release) })
+		sub := New(true)
+		sub.Mux().Get("/events", func(ctx *Ctx, w *Response, r *Request) error {
+			if err := w.SendEvent(&SSEEvent{Data: "first"}); err != nil {
+				return err
+			}
+			<-release
+			return w.SendEvent(&SSEEvent{Data: "second"})
+		})
+		app := sub
+		path := "/events"
+		if mounted {
+			app = New(true)
+			app.Mux().Mount("/sub", sub)
+			path = "/sub/events"
+		}
+		srv := httptest.NewServer(app)
+		//未进入流式响应时，header要等到处理函数返回才会发送
+		client := &http.Client{Timeout: 5 * time.Second}
+		res, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatal("TestStream header fatal", mounted, res.Header)
+		}
+		lines := make(chan string)
+		go func() {
+			br := bufio.NewReader(res.Body)
+			for {
+				line, err := br.ReadString('\n')
+				if err != nil {
+					close(lines)
+					return
+				}
+				lines <- line
+			}
+		}()
+		select {
+		case line := <-lines:
+			if line != "data: first\n" {
+				t.Fatal("TestStream first fatal", mounted, line)
+			}
+		case <-time.After(time.Second):
+			t.Error("TestStream first event not delivered before handler returned", mounted)
+			return
+		}
+		once.Do(func() { close(release) })
+		var rest string
+		for line := range lines {
+			rest += line
+		}
+		if rest != "\ndata: second\n\n" {
+			t.Fatalf("TestStream second fatal %v %q", mounted, rest)
+		}
+		_ = res.Body.Close()
+		srv.Close()
+	}
+}
@@ -1,7 +1,9 @@
 package slim
 
 import (
+	"github.com/buexplain/go-slim/tree"
 	"net/http"
+	"reflect"
 	"regexp"
 	"strings"
 )
@@ -11,9 +13,13 @@ type RouteSetInterface interface {
 	AddLabel(label ...string) RouteSetInterface
 	Use(m ...Middleware) RouteSetInterface
 	Regexp(key string, pattern string) RouteSetInterface
+	SetSchema(request interface{}, response interface{}) RouteSetInterface
+	SetErrorFunc(errorFunc ErrorFunc) RouteSetInterface
+	SetRecoverFunc(recoverFunc RecoverFunc) RouteSetInterface
 }
 
 type RouteGetInterface interface {
+	GetHost() string
 	GetPath() string
 	GetName() string
 	HasLabel(label string) bool
@@ -29,6 +35,35 @@ type Route struct {
 	name       string
 	label      []string
 	regexp     map[string]*regexp.Regexp
+	//参数被替换为参数类型后的路径
+	pattern string
+	//路径中的参数
+	params []tree.PathParam
+	//路由所属的域名模式，为空则不限制域名
+	host string
+	//是否区分大小写
+	strict bool
+	//请求数据的类型，用于生成接口文档
+	request reflect.Type
+	//响应数据的类型，用于生成接口文档
+	response reflect.Type
+	//名称是否由使用者设置，而不是自动生成的
+	named bool
+	//注册的顺序
+	seq int
+	//路由自身的中间件，middleware是路由组的中间件与自身的中间件合并后的结果
+	self []Middleware
+	//路由所属的路由组，从外到内排列
+	groups []*RouteGroup
+	//路由的错误处理，为nil时使用路由组的或者是app的
+	errorFunc ErrorFunc
+	//路由的恐慌恢复，为nil时使用路由组的或者是app的
+	recoverFunc RecoverFunc
+	//挂载的handler，不是挂载的路由则为nil
+	mount http.Handler
+	//路由自身与路由组合并后的错误处理与恐慌恢复，都没有设置时为nil
+	currErrorFunc   ErrorFunc
+	currRecoverFunc RecoverFunc
 }
 
 func NewRoute(mux *Mux, path string, methods []string, handler Handler) *Route {
@@ -37,29 +72,11 @@ func NewRoute(mux *Mux, path string, methods []string, handler Handler) *Route {
 	tmp.setPath(path)
 	tmp.methods = []string{}
 	if len(methods) == 0 || strings.ToUpper(methods[0]) == "ANY" {
-		methods = []string{
-			http.MethodOptions,
-			http.MethodHead,
-			http.MethodGet,
-			http.MethodPost,
-			http.MethodPatch,
-			http.MethodPut,
-			http.MethodDelete,
-			http.MethodTrace,
-			http.MethodConnect,
-		}
+		methods = Methods()
 	}
 	for _, method := range methods {
 		method = strings.ToUpper(method)
-		if method == http.MethodOptions ||
-			method == http.MethodHead ||
-			method == http.MethodGet ||
-			method == http.MethodPost ||
-			method == http.MethodPatch ||
-			method == http.MethodPut ||
-			method == http.MethodDelete ||
-			method == http.MethodTrace ||
-			method == http.MethodConnect {
+		if isMethod(method) {
 			tmp.methods = append(tmp.methods, method)
 		} else {
 			panic("unknown http method: " + method)
@@ -69,6 +86,7 @@ func NewRoute(mux *Mux, path string, methods []string, handler Handler) *Route {
 		panic("unknown http method")
 	}
 	tmp.middleware = []Middleware{}
+	tmp.self = []Middleware{}
 	tmp.handler = handler
 	tmp.label = []string{}
 	tmp.regexp = make(map[string]*regexp.Regexp, len(mux.regexp))
@@ -78,6 +96,10 @@ func NewRoute(mux *Mux, path string, methods []string, handler Handler) *Route {
 	return tmp
 }
 
+func (this *Route) GetHost() string {
+	return this.host
+}
+
 func (this *Route) GetPath() string {
 	return this.path
 }
@@ -87,13 +109,20 @@ func (this *Route) setPath(path string) {
 }
 
 func (this *Route) SetName(name string) RouteSetInterface {
-	if _, ok := this.mux.routeMap[name]; ok {
+	this.mux.modify(func() {
+		this.setName(name)
+		this.named = true
+	})
+	return this
+}
+
+func (this *Route) setName(name string) {
+	if _, ok := this.mux.table.routeMap[name]; ok {
 		panic("route name already exists: " + name)
 	}
-	delete(this.mux.routeMap, this.name)
+	delete(this.mux.table.routeMap, this.name)
 	this.name = name
-	this.mux.routeMap[name] = this
-	return this
+	this.mux.table.routeMap[name] = this
 }
 
 func (this *Route) GetName() string {
@@ -101,13 +130,19 @@ func (this *Route) GetName() string {
 }
 
 func (this *Route) AddLabel(label ...string) RouteSetInterface {
+	this.mux.modify(func() {
+		this.addLabel(label...)
+	})
+	return this
+}
+
+func (this *Route) addLabel(label ...string) {
 	for _, v := range label {
 		if v == "" || this.HasLabel(v) {
 			continue
 		}
 		this.label = append(this.label, v)
 	}
-	return this
 }
 
 func (this *Route) HasLabel(label string) bool {
@@ -124,16 +159,91 @@ func (this *Route) GetLabel() []string {
 }
 
 func (this *Route) Use(m ...Middleware) RouteSetInterface {
-	for _, v := range m {
-		if v == nil {
-			continue
+	this.mux.modify(func() {
+		for _, v := range m {
+			if v == nil {
+				continue
+			}
+			this.self = append(this.self, v)
 		}
-		this.middleware = append(this.middleware, v)
-	}
+		this.rebuild()
+	})
 	return this
 }
 
+//合并路由组的中间件与路由自身的中间件，路由组的中间件从外到内排列，路由自身的中间件最后执行
+//错误处理与恐慌恢复按路由自身、路由组从内到外的顺序取第一个设置了的
+func (this *Route) rebuild() {
+	this.currErrorFunc = this.errorFunc
+	this.currRecoverFunc = this.recoverFunc
+	for i := len(this.groups) - 1; i >= 0; i-- {
+		if this.currErrorFunc == nil {
+			this.currErrorFunc = this.groups[i].errorFunc
+		}
+		if this.currRecoverFunc == nil {
+			this.currRecoverFunc = this.groups[i].recoverFunc
+		}
+	}
+	l := len(this.self)
+	for _, g := range this.groups {
+		l += len(g.middleware)
+	}
+	middleware := make([]Middleware, 0, l)
+	for _, g := range this.groups {
+		middleware = append(middleware, g.middleware...)
+	}
+	this.middleware = append(middleware, this.self...)
+}
+
 func (this *Route) Regexp(key string, pattern string) RouteSetInterface {
-	this.regexp[key] = regexp.MustCompile(pattern)
+	tmp := regexp.MustCompile(pattern)
+	this.mux.modify(func() {
+		this.regexp[key] = tmp
+	})
+	return this
+}
+
+//设置请求与响应数据的类型，用于生成接口文档，传入对应类型的零值即可，比如 SetSchema(LoginForm{}, nil)
+func (this *Route) SetSchema(request interface{}, response interface{}) RouteSetInterface {
+	this.mux.modify(func() {
+		if request != nil {
+			this.request = reflect.TypeOf(request)
+		}
+		if response != nil {
+			this.response = reflect.TypeOf(response)
+		}
+	})
+	return this
+}
+
+//复制路由，动态模式下供请求读取的路由表中存储的是路由的副本
+func (this *Route) clone() *Route {
+	tmp := new(Route)
+	*tmp = *this
+	tmp.middleware = append(make([]Middleware, 0, len(this.middleware)), this.middleware...)
+	tmp.self = append(make([]Middleware, 0, len(this.self)), this.self...)
+	tmp.label = append(make([]string, 0, len(this.label)), this.label...)
+	tmp.regexp = make(map[string]*regexp.Regexp, len(this.regexp))
+	for k, v := range this.regexp {
+		tmp.regexp[k] = v
+	}
+	return tmp
+}
+
+//设置路由的错误处理，优先于路由组与app的错误处理
+func (this *Route) SetErrorFunc(errorFunc ErrorFunc) RouteSetInterface {
+	this.mux.modify(func() {
+		this.errorFunc = errorFunc
+		this.rebuild()
+	})
+	return this
+}
+
+//设置路由的恐慌恢复，优先于路由组与app的恐慌恢复
+func (this *Route) SetRecoverFunc(recoverFunc RecoverFunc) RouteSetInterface {
+	this.mux.modify(func() {
+		this.recoverFunc = recoverFunc
+		this.rebuild()
+	})
 	return this
 }
@@ -1,7 +1,6 @@
 package slim
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 )
@@ -9,7 +8,19 @@ import (
 type RouteGroup struct {
 	prefix     string
 	data       []*Route
-	middleware map[string][]Middleware
+	middleware []Middleware
+	label      []string
+	regexp     map[string]*regexp.Regexp
+	mux        *Mux
+	//路由组所属的域名
+	host *routeHost
+	//路由组内的路由是否区分大小写
+	strict bool
+	//外层的路由组，从外到内排列
+	parents []*RouteGroup
+	//路由组的错误处理与恐慌恢复，为nil时使用外层路由组的或者是app的
+	errorFunc   ErrorFunc
+	recoverFunc RecoverFunc
 }
 
 func NewRouteGroup(prefix string) *RouteGroup {
@@ -19,44 +30,107 @@ func NewRouteGroup(prefix string) *RouteGroup {
 	if prefix != "" {
 		prefix = "/" + strings.Trim(prefix, "/")
 	}
-	return &RouteGroup{prefix: prefix, data: make([]*Route, 0), middleware: map[string][]Middleware{}}
+	return &RouteGroup{prefix: prefix, data: make([]*Route, 0), middleware: []Middleware{}, label: []string{}, regexp: map[string]*regexp.Regexp{}}
 }
 
 func (this *RouteGroup) addRoute(route *Route) {
 	this.data = append(this.data, route)
 }
 
-func (this *RouteGroup) AddLabel(label ...string) *RouteGroup {
-	for _, v := range this.data {
-		v.AddLabel(label...)
+//修改路由组，动态模式下需要通过Mux修改
+func (this *RouteGroup) modify(f func()) {
+	if this.mux == nil {
+		f()
+		return
 	}
+	this.mux.modify(f)
+}
+
+//给组内已注册的路由以及之后注册的路由添加标签
+func (this *RouteGroup) AddLabel(label ...string) *RouteGroup {
+	this.modify(func() {
+		this.label = append(this.label, label...)
+		for _, v := range this.data {
+			v.addLabel(label...)
+		}
+	})
 	return this
 }
 
+//给组内已注册的路由以及之后注册的路由添加中间件
 func (this *RouteGroup) Use(m ...Middleware) *RouteGroup {
 	if m == nil {
 		return this
 	}
-	for _, v := range this.data {
-		key := fmt.Sprintf("%p", v)
-		if _, ok := this.middleware[key]; !ok {
-			this.middleware[key] = []Middleware{}
-		}
-		self := v.middleware[len(this.middleware[key]):]
-		v.middleware = []Middleware{}
-		this.middleware[key] = append(this.middleware[key], m...)
-		v.Use(this.middleware[key]...)
-		v.Use(self...)
-	}
+	this.modify(func() {
+		for _, v := range m {
+			if v == nil {
+				continue
+			}
+			this.middleware = append(this.middleware, v)
+		}
+		for _, v := range this.data {
+			v.rebuild()
+		}
+	})
 	return this
 }
 
+//给组内已注册的路由以及之后注册的路由设置参数的正则，路由已经设置了同名的正则时不覆盖
 func (this *RouteGroup) Regexp(key string, pattern string) *RouteGroup {
 	tmp := regexp.MustCompile(pattern)
-	for _, v := range this.data {
-		if _, ok := v.regexp[key]; !ok {
-			v.regexp[key] = tmp
+	this.modify(func() {
+		this.regexp[key] = tmp
+		for _, v := range this.data {
+			if _, ok := v.regexp[key]; !ok {
+				v.regexp[key] = tmp
+			}
 		}
-	}
+	})
+	return this
+}
+
+//设置组内路由的错误处理，路由自身设置的优先，嵌套的路由组内层的优先
+func (this *RouteGroup) SetErrorFunc(errorFunc ErrorFunc) *RouteGroup {
+	this.modify(func() {
+		this.errorFunc = errorFunc
+		for _, v := range this.data {
+			v.rebuild()
+		}
+	})
+	return this
+}
+
+//设置组内路由的恐慌恢复，路由自身设置的优先，嵌套的路由组内层的优先
+func (this *RouteGroup) SetRecoverFunc(recoverFunc RecoverFunc) *RouteGroup {
+	this.modify(func() {
+		this.recoverFunc = recoverFunc
+		for _, v := range this.data {
+			v.rebuild()
+		}
+	})
 	return this
 }
+
+//设置路由组前缀下未命中任何路由时的路由，嵌套的路由组前缀更长的优先，都没有设置则使用 Mux.SetDefaultRoute 设置的
+//该路由属于当前路由组，路由组的中间件、错误处理、恐慌恢复同样对其生效
+func (this *RouteGroup) SetDefaultRoute(handler Handler) RouteSetInterface {
+	if this.mux == nil {
+		panic("route group not registered by mux")
+	}
+	route := NewRoute(this.mux, this.prefix, nil, handler)
+	this.mux.modify(func() {
+		if this.host != nil {
+			route.host = this.host.pattern
+		}
+		route.strict = this.strict
+		route.groups = append(append(make([]*RouteGroup, 0, len(this.parents)+1), this.parents...), this)
+		for _, v := range route.groups {
+			v.addRoute(route)
+			route.addLabel(v.label...)
+		}
+		route.rebuild()
+		this.mux.table.addDefault(route)
+	})
+	return route
+}
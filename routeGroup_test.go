@@ -0,0 +1,153 @@
+package slim
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+//返回一个将错误写入响应的错误处理函数
+func testErrorFunc(name string) ErrorFunc {
+	return func(ctx *Ctx, err error) {
+		if err != nil {
+			_ = ctx.Response().Plain(http.StatusInternalServerError, name+": "+err.Error())
+		}
+	}
+}
+
+//返回一个将恐慌写入响应的恐慌恢复函数
+func testRecoverFunc(name string) RecoverFunc {
+	return func(ctx *Ctx, a interface{}) {
+		_ = ctx.Response().Plain(http.StatusInternalServerError, name+": "+fmt.Sprint(a))
+	}
+}
+
+//测试错误处理与恐慌恢复的优先级：路由、内层路由组、外层路由组、app
+func TestGroupErrorFunc(t *testing.T) {
+	app := New(true)
+	app.SetErrorFunc(testErrorFunc("app"))
+	app.SetRecoverFunc(testRecoverFunc("app"))
+	mux := app.Mux()
+	fail := func(ctx *Ctx, w *Response, r *Request) error {
+		if r.Query("panic") != "" {
+			panic("boom")
+		}
+		return errors.New("fail")
+	}
+	mux.Get("/a", fail)
+	mux.Group("/outer", func(outer *RouteGroup) {
+		outer.SetErrorFunc(testErrorFunc("outer")).SetRecoverFunc(testRecoverFunc("outer"))
+		mux.Get("/a", fail)
+		mux.Group("/mid", func() {
+			mux.Get("/a", fail)
+		})
+		mux.Group("/inner", func(inner *RouteGroup) {
+			mux.Get("/a", fail)
+			mux.Get("/self", fail).SetErrorFunc(testErrorFunc("route")).SetRecoverFunc(testRecoverFunc("route"))
+			//在注册路由之后设置同样生效
+			inner.SetErrorFunc(testErrorFunc("inner")).SetRecoverFunc(testRecoverFunc("inner"))
+		})
+	})
+	for target, name := range map[string]string{
+		"/a":                "app",
+		"/outer/a":          "outer",
+		"/outer/mid/a":      "outer",
+		"/outer/inner/a":    "inner",
+		"/outer/inner/self": "route",
+	} {
+		if rec := serve(app, http.MethodGet, target); rec.Body.String() != name+": fail" {
+			t.Fatal("TestGroupErrorFunc fatal", target, rec.Body.String())
+		}
+		if rec := serve(app, http.MethodGet, target+"?panic=1"); rec.Body.String() != name+": boom" {
+			t.Fatal("TestGroupErrorFunc recover fatal", target, rec.Body.String())
+		}
+	}
+}
+
+//测试未命中路由时，使用路径前缀最长的路由组设置的路由，并执行路由组的中间件
+func TestGroupDefaultRoute(t *testing.T) {
+	app := New(true)
+	mux := app.Mux()
+	mux.SetDefaultRoute(func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusNotFound, "mux")
+	})
+	notFound := func(name string) Handler {
+		return func(ctx *Ctx, w *Response, r *Request) error {
+			return w.Plain(http.StatusNotFound, name+w.Header().Get("X-Group"))
+		}
+	}
+	mux.Group("/outer", func(outer *RouteGroup) {
+		outer.Use(func(ctx *Ctx, w *Response, r *Request) {
+			w.Header().Set("X-Group", " outer")
+			ctx.Next()
+		})
+		outer.SetDefaultRoute(notFound("outer"))
+		mux.Get("/a", func(ctx *Ctx, w *Response, r *Request) error {
+			return w.Plain(http.StatusOK, "a")
+		})
+		mux.Group("/inner", func(inner *RouteGroup) {
+			inner.SetDefaultRoute(notFound("inner"))
+		})
+	})
+	for target, body := range map[string]string{
+		"/outer/a":         "a",
+		"/outer":           "outer outer",
+		"/outer/x":         "outer outer",
+		"/outer/inner":     "inner outer",
+		"/outer/inner/x/y": "inner outer",
+		"/outer/innerx":    "outer outer",
+		"/outerx":          "mux",
+		"/x":               "mux",
+	} {
+		if rec := serve(app, http.MethodGet, target); rec.Body.String() != body {
+			t.Fatal("TestGroupDefaultRoute fatal", target, rec.Body.String())
+		}
+	}
+}
+
+//测试中间件的顺序：全局、外层路由组（包括之后设置的）、内层路由组、路由，以及正则与标签的继承
+func TestGroupMiddlewareOrder(t *testing.T) {
+	app := New(true)
+	mux := app.Mux()
+	mark := func(name string) Middleware {
+		return func(ctx *Ctx, w *Response, r *Request) {
+			w.Header().Add("X-Order", name)
+			ctx.Next()
+		}
+	}
+	app.Use(mark("app"))
+	handler := func(ctx *Ctx, w *Response, r *Request) error {
+		return w.Plain(http.StatusOK, r.Param("id"))
+	}
+	var route RouteSetInterface
+	mux.Group("/outer", func(outer *RouteGroup) {
+		outer.Use(mark("outer1")).Regexp("id", `^\d+$`).AddLabel("outer")
+		mux.Group("/inner", func(inner *RouteGroup) {
+			inner.Use(mark("inner")).Regexp("id", `^[a-z]+$`)
+			route = mux.Get("/:id", handler).Use(mark("route"))
+		})
+		mux.Get("/:id", handler)
+		//之后设置的中间件追加在外层路由组已有的中间件之后，对已注册的路由同样生效
+		outer.Use(mark("outer2")).AddLabel("later")
+	})
+
+	rec := serve(app, http.MethodGet, "/outer/inner/abc")
+	if fmt.Sprint(rec.Header()["X-Order"]) != "[app outer1 outer2 inner route]" || rec.Body.String() != "abc" {
+		t.Fatal("TestGroupMiddlewareOrder fatal", rec.Header()["X-Order"], rec.Body.String())
+	}
+	rec = serve(app, http.MethodGet, "/outer/10")
+	if fmt.Sprint(rec.Header()["X-Order"]) != "[app outer1 outer2]" || rec.Body.String() != "10" {
+		t.Fatal("TestGroupMiddlewareOrder fatal", rec.Header()["X-Order"], rec.Body.String())
+	}
+	//内层路由组的正则优先
+	if rec := serve(app, http.MethodGet, "/outer/inner/10"); rec.Body.String() == "10" {
+		t.Fatal("TestGroupMiddlewareOrder regexp fatal", rec.Body.String())
+	}
+	if rec := serve(app, http.MethodGet, "/outer/abc"); rec.Body.String() == "abc" {
+		t.Fatal("TestGroupMiddlewareOrder regexp fatal", rec.Body.String())
+	}
+	if r := route.(*Route); !r.HasLabel("outer") || !r.HasLabel("later") {
+		t.Fatal("TestGroupMiddlewareOrder label fatal", r.GetLabel())
+	}
+}
@@ -1,46 +1,181 @@
 package slim
 
 import (
-	"reflect"
-	"runtime"
+	"net/http"
 	"strings"
 )
 
-type RouteRestful struct {
-	data []*Route
+//资源的动作
+const (
+	ActionIndex   = "index"
+	ActionCreate  = "create"
+	ActionStore   = "store"
+	ActionShow    = "show"
+	ActionEdit    = "edit"
+	ActionUpdate  = "update"
+	ActionDestroy = "destroy"
+)
+
+//资源的动作，按注册的顺序排列
+var resourceActions = []string{ActionIndex, ActionCreate, ActionStore, ActionShow, ActionEdit, ActionUpdate, ActionDestroy}
+
+//资源路由的路径风格
+type ResourceStyle int
+
+const (
+	//与 Mux.Restful 一致的风格：/create、/show/:id、/edit/:id、/update/:id、/delete/:id
+	ResourceStyleClassic ResourceStyle = iota
+	//类似 Rails 的风格：/create、/:id、/:id/edit
+	ResourceStyleRails
+)
+
+//资源控制器的接口，控制器实现了哪些接口就注册哪些动作
+type ResourceIndex interface {
+	Index(ctx *Ctx, w *Response, r *Request) error
 }
 
-func (this *RouteRestful) SetName(name string) RouteSetInterface {
-	for _, v := range this.data {
-		fn := runtime.FuncForPC(reflect.ValueOf(v.handler).Pointer()).Name()
-		tmp := strings.LastIndex(fn, ".")
-		if tmp == -1 {
-			continue
+type ResourceCreate interface {
+	Create(ctx *Ctx, w *Response, r *Request) error
+}
+
+type ResourceStore interface {
+	Store(ctx *Ctx, w *Response, r *Request) error
+}
+
+type ResourceShow interface {
+	Show(ctx *Ctx, w *Response, r *Request) error
+}
+
+type ResourceEdit interface {
+	Edit(ctx *Ctx, w *Response, r *Request) error
+}
+
+type ResourceUpdate interface {
+	Update(ctx *Ctx, w *Response, r *Request) error
+}
+
+type ResourceDestroy interface {
+	Destroy(ctx *Ctx, w *Response, r *Request) error
+}
+
+//资源的动作与处理函数的映射
+type ResourceActions map[string]Handler
+
+//根据控制器实现的接口生成资源的动作
+func NewResourceActions(controller interface{}) ResourceActions {
+	tmp := ResourceActions{}
+	if c, ok := controller.(ResourceIndex); ok {
+		tmp[ActionIndex] = c.Index
+	}
+	if c, ok := controller.(ResourceCreate); ok {
+		tmp[ActionCreate] = c.Create
+	}
+	if c, ok := controller.(ResourceStore); ok {
+		tmp[ActionStore] = c.Store
+	}
+	if c, ok := controller.(ResourceShow); ok {
+		tmp[ActionShow] = c.Show
+	}
+	if c, ok := controller.(ResourceEdit); ok {
+		tmp[ActionEdit] = c.Edit
+	}
+	if c, ok := controller.(ResourceUpdate); ok {
+		tmp[ActionUpdate] = c.Update
+	}
+	if c, ok := controller.(ResourceDestroy); ok {
+		tmp[ActionDestroy] = c.Destroy
+	}
+	return tmp
+}
+
+//只保留指定的动作
+func (this ResourceActions) Only(action ...string) ResourceActions {
+	tmp := ResourceActions{}
+	for _, v := range action {
+		if h, ok := this[v]; ok {
+			tmp[v] = h
+		}
+	}
+	return tmp
+}
+
+//排除指定的动作
+func (this ResourceActions) Except(action ...string) ResourceActions {
+	tmp := ResourceActions{}
+	for k, v := range this {
+		tmp[k] = v
+	}
+	for _, v := range action {
+		delete(tmp, v)
+	}
+	return tmp
+}
+
+//资源的动作对应的请求方法与路径，member为带资源参数的路径，比如 /:id
+func resourceRoute(action string, style ResourceStyle, base string, member string) ([]string, string) {
+	switch action {
+	case ActionIndex:
+		return []string{http.MethodGet}, base
+	case ActionCreate:
+		return []string{http.MethodGet}, base + "/create"
+	case ActionStore:
+		return []string{http.MethodPost}, base
+	case ActionShow:
+		if style == ResourceStyleRails {
+			return []string{http.MethodGet}, base + member
+		}
+		return []string{http.MethodGet}, base + "/show" + member
+	case ActionEdit:
+		if style == ResourceStyleRails {
+			return []string{http.MethodGet}, base + member + "/edit"
 		}
-		fn = fn[tmp+1:]
-		switch true {
-		case strings.EqualFold(fn, "index"):
-			v.SetName(name + ".index")
-			break
-		case strings.EqualFold(fn, "create"):
-			v.SetName(name + ".create")
-			break
-		case strings.EqualFold(fn, "store"):
-			v.SetName(name + ".store")
-			break
-		case strings.EqualFold(fn, "edit"):
-			v.SetName(name + ".edit")
-			break
-		case strings.EqualFold(fn, "update"):
-			v.SetName(name + ".update")
-			break
-		case strings.EqualFold(fn, "destroy"):
-			v.SetName(name + ".destroy")
-			break
-		case strings.EqualFold(fn, "show"):
-			v.SetName(name + ".show")
-			break
+		return []string{http.MethodGet}, base + "/edit" + member
+	case ActionUpdate:
+		if style == ResourceStyleRails {
+			return []string{http.MethodPut, http.MethodPatch}, base + member
 		}
+		return []string{http.MethodPut, http.MethodPatch}, base + "/update" + member
+	case ActionDestroy:
+		if style == ResourceStyleRails {
+			return []string{http.MethodDelete}, base + member
+		}
+		return []string{http.MethodDelete}, base + "/delete" + member
+	}
+	return nil, ""
+}
+
+type RouteRestful struct {
+	mux *Mux
+	//包含路由组前缀的完整路径
+	path    string
+	style   ResourceStyle
+	data    []*Route
+	actions []string
+	//注册资源时所在的路由组、域名以及是否区分大小写，嵌套的资源沿用这些设置
+	groups []*RouteGroup
+	host   *routeHost
+	strict bool
+}
+
+//注册嵌套的资源，param为父级资源的参数名称，比如 posts.Nest("post_id", "comments", actions) 注册的路径为 /posts/:post_id/comments
+//嵌套的资源注册在父级资源所在的路由组与域名下，与调用时所在的路由组无关
+func (this *RouteRestful) Nest(param string, path string, actions ResourceActions) *RouteRestful {
+	mux := this.mux
+	groups, host, caseSensitive := mux.groups, mux.host, mux.caseSensitive
+	mux.groups, mux.host, mux.caseSensitive = this.groups, this.host, this.strict
+	defer func() {
+		mux.groups, mux.host, mux.caseSensitive = groups, host, caseSensitive
+	}()
+	prefix := ""
+	if len(this.groups) > 0 {
+		prefix = this.groups[len(this.groups)-1].prefix
+	}
+	return mux.Resource(strings.TrimPrefix(this.path, prefix)+"/:"+param+"/"+strings.Trim(path, "/"), actions, this.style)
+}
+
+func (this *RouteRestful) SetName(name string) RouteSetInterface {
+	for k, v := range this.data {
+		v.SetName(name + "." + this.actions[k])
 	}
 	return this
 }
@@ -65,3 +200,24 @@ func (this *RouteRestful) Regexp(key string, pattern string) RouteSetInterface {
 	}
 	return this
 }
+
+func (this *RouteRestful) SetSchema(request interface{}, response interface{}) RouteSetInterface {
+	for _, v := range this.data {
+		v.SetSchema(request, response)
+	}
+	return this
+}
+
+func (this *RouteRestful) SetErrorFunc(errorFunc ErrorFunc) RouteSetInterface {
+	for _, v := range this.data {
+		v.SetErrorFunc(errorFunc)
+	}
+	return this
+}
+
+func (this *RouteRestful) SetRecoverFunc(recoverFunc RecoverFunc) RouteSetInterface {
+	for _, v := range this.data {
+		v.SetRecoverFunc(recoverFunc)
+	}
+	return this
+}
@@ -0,0 +1,127 @@
+package slim
+
+import (
+	"net/http"
+	"testing"
+)
+
+//测试用的资源控制器，响应动作名称与参数
+type testResource struct {
+	name string
+}
+
+func (this testResource) respond(action string, w *Response, r *Request) error {
+	return w.Plain(http.StatusOK, this.name+"."+action+" "+r.Param("post_id")+" "+r.Param("id"))
+}
+
+func (this testResource) Index(ctx *Ctx, w *Response, r *Request) error {
+	return this.respond(ActionIndex, w, r)
+}
+
+func (this testResource) Create(ctx *Ctx, w *Response, r *Request) error {
+	return this.respond(ActionCreate, w, r)
+}
+
+func (this testResource) Store(ctx *Ctx, w *Response, r *Request) error {
+	return this.respond(ActionStore, w, r)
+}
+
+func (this testResource) Show(ctx *Ctx, w *Response, r *Request) error {
+	return this.respond(ActionShow, w, r)
+}
+
+func (this testResource) Edit(ctx *Ctx, w *Response, r *Request) error {
+	return this.respond(ActionEdit, w, r)
+}
+
+func (this testResource) Update(ctx *Ctx, w *Response, r *Request) error {
+	return this.respond(ActionUpdate, w, r)
+}
+
+func (this testResource) Destroy(ctx *Ctx, w *Response, r *Request) error {
+	return this.respond(ActionDestroy, w, r)
+}
+
+//校验请求的响应内容，result为空表示路由不存在
+func checkResource(t *testing.T, app *App, cases [][3]string) {
+	for _, v := range cases {
+		rec := serve(app, v[0], v[1])
+		if v[2] == "" {
+			if rec.Code == http.StatusOK && rec.Header().Get("Content-Type") == "text/plain; charset=utf-8" {
+				t.Fatal("Resource fatal, route should not exist", v[0], v[1], rec.Body.String())
+			}
+			continue
+		}
+		if rec.Body.String() != v[2] {
+			t.Fatal("Resource fatal", v[0], v[1], rec.Code, rec.Body.String())
+		}
+	}
+}
+
+//测试经典风格的资源路由
+func TestResourceClassic(t *testing.T) {
+	app := New(true)
+	app.Mux().Resource("/posts", NewResourceActions(testResource{"posts"})).SetName("posts")
+	checkResource(t, app, [][3]string{
+		{http.MethodGet, "/posts", "posts.index  "},
+		{http.MethodGet, "/posts/create", "posts.create  "},
+		{http.MethodPost, "/posts", "posts.store  "},
+		{http.MethodGet, "/posts/show/1", "posts.show  1"},
+		{http.MethodGet, "/posts/edit/1", "posts.edit  1"},
+		{http.MethodPut, "/posts/update/1", "posts.update  1"},
+		{http.MethodPatch, "/posts/update/1", "posts.update  1"},
+		{http.MethodDelete, "/posts/delete/1", "posts.destroy  1"},
+	})
+	if _, ok := app.Mux().routes().routeMap["posts."+ActionShow]; !ok {
+		t.Fatal("TestResourceClassic name fatal")
+	}
+}
+
+//测试Rails风格的资源路由以及动作的筛选
+func TestResourceRails(t *testing.T) {
+	app := New(true)
+	actions := NewResourceActions(testResource{"posts"})
+	app.Mux().Resource("/posts", actions.Only(ActionIndex, ActionShow, ActionUpdate), ResourceStyleRails)
+	app.Mux().Resource("/tags/", actions.Except(ActionDestroy, ActionEdit), ResourceStyleRails)
+	checkResource(t, app, [][3]string{
+		{http.MethodGet, "/posts", "posts.index  "},
+		{http.MethodGet, "/posts/1", "posts.show  1"},
+		{http.MethodPatch, "/posts/1", "posts.update  1"},
+		{http.MethodPost, "/posts", ""},
+		{http.MethodGet, "/posts/1/edit", ""},
+		{http.MethodDelete, "/posts/1", ""},
+		{http.MethodGet, "/tags/create", "posts.create  "},
+		{http.MethodPost, "/tags", "posts.store  "},
+		{http.MethodGet, "/tags/1", "posts.show  1"},
+		{http.MethodPut, "/tags/1", "posts.update  1"},
+		{http.MethodGet, "/tags/1/edit", ""},
+		{http.MethodDelete, "/tags/1", ""},
+	})
+}
+
+//测试嵌套的资源，在路由组之外调用 Nest 也注册在父级资源所在的路由组下
+func TestResourceNest(t *testing.T) {
+	app := New(true)
+	var posts *RouteRestful
+	var middleware int
+	app.Mux().Group("/admin", func(g *RouteGroup) {
+		g.Use(func(ctx *Ctx, w *Response, r *Request) {
+			middleware++
+			ctx.Next()
+		})
+		posts = app.Mux().Resource("/posts", NewResourceActions(testResource{"posts"}), ResourceStyleRails)
+	})
+	app.Mux().Group("/other", func() {
+		posts.Nest("post_id", "comments", NewResourceActions(testResource{"comments"}).Only(ActionIndex, ActionShow))
+	})
+	checkResource(t, app, [][3]string{
+		{http.MethodGet, "/admin/posts/9", "posts.show  9"},
+		{http.MethodGet, "/admin/posts/9/comments", "comments.index 9 "},
+		{http.MethodGet, "/admin/posts/9/comments/3", "comments.show 9 3"},
+		{http.MethodGet, "/posts/9/comments/3", ""},
+		{http.MethodGet, "/other/admin/posts/9/comments/3", ""},
+	})
+	if middleware != 3 {
+		t.Fatal("TestResourceNest middleware fatal", middleware)
+	}
+}
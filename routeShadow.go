@@ -1,15 +1,34 @@
 package slim
 
-import "strings"
+import (
+	"reflect"
+	"strings"
+)
 
 type RouteShadow struct {
-	Path       string
-	Methods    []string
-	Middleware []string
-	Handler    string
-	Name       string
-	Label      []string
-	Regexp     map[string]string
+	Host       string             `json:"host"`
+	Path       string             `json:"path"`
+	Methods    []string           `json:"methods"`
+	Middleware []string           `json:"middleware"`
+	Handler    string             `json:"handler"`
+	Name       string             `json:"name"`
+	Label      []string           `json:"label"`
+	Regexp     map[string]string  `json:"regexp"`
+	Params     []RouteShadowParam `json:"params"`
+	//请求与响应数据的类型，未设置时为nil
+	Request  reflect.Type `json:"-"`
+	Response reflect.Type `json:"-"`
+	//是否是挂载的路由
+	mount bool
+}
+
+//路由路径中的参数
+type RouteShadowParam struct {
+	//参数类型，":"是普通参数，"*"是通配参数
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	//参数约束，内置类型或者是正则表达式
+	Constraint string `json:"constraint,omitempty"`
 }
 
 type RouteShadowSlice []RouteShadow
@@ -0,0 +1,52 @@
+package slim
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+//测试用的session，不加锁，用于配合 -race 检查并发访问
+type testSession struct {
+	store map[interface{}]interface{}
+	saved *int32
+}
+
+func (this *testSession) Get(k interface{}) interface{}    { return this.store[k] }
+func (this *testSession) GetString(k interface{}) string   { s, _ := this.store[k].(string); return s }
+func (this *testSession) GetInt(k interface{}) int         { i, _ := this.store[k].(int); return i }
+func (this *testSession) GetFloat64(k interface{}) float64 { f, _ := this.store[k].(float64); return f }
+func (this *testSession) GetFloat32(k interface{}) float32 { f, _ := this.store[k].(float32); return f }
+func (this *testSession) Pull(k interface{}) interface{} {
+	v := this.store[k]
+	delete(this.store, k)
+	return v
+}
+func (this *testSession) PullString(k interface{}) string   { s, _ := this.Pull(k).(string); return s }
+func (this *testSession) PullInt(k interface{}) int         { i, _ := this.Pull(k).(int); return i }
+func (this *testSession) PullFloat64(k interface{}) float64 { f, _ := this.Pull(k).(float64); return f }
+func (this *testSession) PullFloat32(k interface{}) float32 { f, _ := this.Pull(k).(float32); return f }
+func (this *testSession) Set(k, v interface{})              { this.store[k] = v }
+func (this *testSession) Del(k interface{})                 { delete(this.store, k) }
+func (this *testSession) Has(k interface{}) bool            { _, ok := this.store[k]; return ok }
+func (this *testSession) ID() string                        { return "test" }
+func (this *testSession) Name() string                      { return "session" }
+func (this *testSession) Regenerate()                       {}
+func (this *testSession) Destroy()                          {}
+
+//落地时读取全部条目，并写入cookie
+func (this *testSession) Save(r *http.Request, w http.ResponseWriter) error {
+	for range this.store {
+	}
+	atomic.AddInt32(this.saved, 1)
+	http.SetCookie(w, &http.Cookie{Name: this.Name(), Value: this.ID()})
+	return nil
+}
+
+//测试用的session处理器，记录session落地的次数
+type testSessionHandler struct {
+	saved int32
+}
+
+func (this *testSessionHandler) Get(r *Request) (Session, error) {
+	return &testSession{store: map[interface{}]interface{}{}, saved: &this.saved}, nil
+}
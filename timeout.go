@@ -0,0 +1,198 @@
+package slim
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/buexplain/go-slim/errors"
+	"github.com/buexplain/go-slim/tsmap"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	//处理中
+	timeoutRunning int32 = iota
+	//处理完毕
+	timeoutFinished
+	//已超时，处理结果被丢弃
+	timeoutAbandoned
+)
+
+//超时中间件中，后续的中间件与处理函数使用的http.ResponseWriter
+//header写入私有的副本，直接写入的状态码与内容（包括流式响应）先暂存，处理完毕后才复制到真正的响应上
+type timeoutWriter struct {
+	header     http.Header
+	statusCode int
+	buffer     bytes.Buffer
+}
+
+func (this *timeoutWriter) Header() http.Header {
+	return this.header
+}
+
+func (this *timeoutWriter) Write(b []byte) (int, error) {
+	if this.statusCode == 0 {
+		this.statusCode = http.StatusOK
+	}
+	return this.buffer.Write(b)
+}
+
+func (this *timeoutWriter) WriteHeader(statusCode int) {
+	if this.statusCode == 0 {
+		this.statusCode = statusCode
+	}
+}
+
+//超时中间件，可以作为全局中间件、路由组中间件、路由中间件使用
+//后续的中间件与处理函数在新的协程中，使用当前上下文的副本执行，超时后取消请求的context.Context，丢弃已经写入的响应，
+//并通过错误处理响应503；被丢弃的上下文副本在其协程结束后才会被回收，之后的写入不会影响到其它请求
+//超时后，后续的中间件与处理函数仍然在执行，需要通过 Ctx 的 Done 或者是 Err 感知超时并尽快返回
+//超时中间件之后的流式响应会被暂存，处理完毕后才发送给客户端
+func Timeout(timeout time.Duration) Middleware {
+	return func(ctx *Ctx, w *Response, r *Request) {
+		c, cancel := context.WithTimeout(ctx.Context(), timeout)
+		defer cancel()
+		sub := ctx.fork(c)
+		var state int32 = timeoutRunning
+		var panicValue interface{}
+		done := make(chan struct{})
+		go func() {
+			defer func() {
+				if a := recover(); a != nil {
+					panicValue = a
+				}
+				if atomic.CompareAndSwapInt32(&state, timeoutRunning, timeoutFinished) {
+					close(done)
+				} else {
+					//已经超时，超时之后的恐慌交给恐慌恢复函数记录，并由当前协程回收上下文副本
+					if panicValue != nil {
+						sub.recoverAbandoned(panicValue)
+					}
+					sub.release()
+					ctx.app.pool.Put(sub)
+				}
+			}()
+			sub.Next()
+		}()
+		select {
+		case <-done:
+		case <-c.Done():
+			if atomic.CompareAndSwapInt32(&state, timeoutRunning, timeoutAbandoned) {
+				ctx.abandon(errors.Mark(fmt.Errorf("request timeout: %w", c.Err()), http.StatusServiceUnavailable))
+				return
+			}
+			//超时的同时处理完毕了
+			<-done
+		}
+		if c.Err() == context.DeadlineExceeded {
+			//处理完毕时已经超时，处理函数很可能是感知到超时才返回的，结果同样丢弃
+			if panicValue != nil {
+				sub.recoverAbandoned(panicValue)
+			}
+			sub.release()
+			ctx.app.pool.Put(sub)
+			ctx.abandon(errors.Mark(fmt.Errorf("request timeout: %w", c.Err()), http.StatusServiceUnavailable))
+			return
+		}
+		ctx.join(sub)
+		sub.release()
+		ctx.app.pool.Put(sub)
+		if panicValue != nil {
+			panic(panicValue)
+		}
+	}
+}
+
+//复制当前上下文，用于在新的协程中继续执行后续的中间件与处理函数
+func (this *Ctx) fork(c context.Context) *Ctx {
+	sub := this.app.pool.Get().(*Ctx)
+	tw := &timeoutWriter{header: http.Header{}}
+	for k, v := range this.w.w.Header() {
+		tw.header[k] = append([]string(nil), v...)
+	}
+	sub.reset(tw, this.r.r.WithContext(c))
+	sub.middleware = this.middleware
+	sub.nextI = this.nextI
+	sub.nextJ = this.nextJ
+	sub.route = this.route
+	sub.routeMatchPath = this.routeMatchPath
+	sub.rawPath = this.rawPath
+	copyStore(sub.store, this.store)
+	copyStore(sub.r.param, this.r.param)
+	sub.r.session = this.r.session
+	copyStore(sub.w.store, this.w.store)
+	sub.w.statusCode = this.w.statusCode
+	sub.w.buffer.Write(this.w.buffer.Bytes())
+	return sub
+}
+
+//合并处理完毕的上下文副本
+func (this *Ctx) join(sub *Ctx) {
+	this.nextI = sub.nextI
+	this.nextJ = sub.nextJ
+	this.route = sub.route
+	this.routeMatchPath = sub.routeMatchPath
+	this.rawPath = sub.rawPath
+	this.store.Release()
+	copyStore(this.store, sub.store)
+	this.r.param.Release()
+	copyStore(this.r.param, sub.r.param)
+	this.r.session = sub.r.session
+	this.w.store.Release()
+	copyStore(this.w.store, sub.w.store)
+	header := this.w.w.Header()
+	for k := range header {
+		delete(header, k)
+	}
+	for k, v := range sub.w.w.Header() {
+		header[k] = v
+	}
+	this.w.buffer.Reset()
+	tw := sub.w.w.(*timeoutWriter)
+	if tw.statusCode != 0 {
+		//直接写入过http.ResponseWriter，或者是进入了流式响应模式
+		this.w.statusCode = tw.statusCode
+		_, _ = tw.buffer.WriteTo(this.w.buffer)
+	} else {
+		this.w.statusCode = sub.w.statusCode
+	}
+	_, _ = sub.w.buffer.WriteTo(this.w.buffer)
+	if sub.w.stream {
+		//流式响应已经保存了session
+		this.r.session = nil
+	}
+}
+
+//超时后丢弃已经写入的响应，结束中间件循环，并通过错误处理响应
+func (this *Ctx) abandon(err error) {
+	if this.route == nil {
+		this.route = this.app.mux.match(this)
+	}
+	this.nextI = len(this.middleware)
+	this.nextJ = len(this.route.middleware) + 1
+	//session仍然被超时的协程持有，写了一半的session不能保存
+	this.r.session = nil
+	this.w.statusCode = 0
+	this.w.buffer.Reset()
+	this.w.store.Release()
+	this.Throw(err)
+}
+
+//在超时的协程中调用恐慌恢复函数，响应会被丢弃，恐慌恢复函数本身的恐慌也会被忽略，避免进程退出
+func (this *Ctx) recoverAbandoned(a interface{}) {
+	defer func() {
+		_ = recover()
+	}()
+	this.w.buffer.Reset()
+	this.recoverFunc()(this, a)
+}
+
+//复制存储容器中的条目
+func copyStore(dst *tsmap.TSMap, src *tsmap.TSMap) {
+	src.Range(func(key string, v interface{}) bool {
+		dst.Set(key, v)
+		return true
+	})
+}
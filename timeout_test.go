@@ -0,0 +1,108 @@
+package slim
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//测试超时中间件正常响应与超时响应503
+func TestTimeout(t *testing.T) {
+	app := New(true)
+	app.Mux().Get("/fast", func(ctx *Ctx, w *Response, r *Request) error {
+		w.Header().Set("X-Fast", "1")
+		return w.Plain(http.StatusCreated, "ok")
+	}).Use(Timeout(time.Second))
+	handlerDone := make(chan struct{})
+	app.Mux().Get("/slow", func(ctx *Ctx, w *Response, r *Request) error {
+		defer close(handlerDone)
+		_, _ = w.Write([]byte("partial"))
+		<-ctx.Done()
+		_, _ = w.Write([]byte("late"))
+		return nil
+	}).Use(Timeout(5 * time.Millisecond))
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if rec.Code != http.StatusCreated || rec.Body.String() != "ok" || rec.Header().Get("X-Fast") != "1" {
+		t.Fatal("TestTimeout fast fatal", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatal("TestTimeout slow fatal", rec.Code, rec.Body.String())
+	}
+	<-handlerDone
+	//上下文副本被回收后，再次处理请求不会带上超时协程写入的内容
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if rec.Body.String() != "ok" {
+		t.Fatal("TestTimeout reuse fatal", rec.Body.String())
+	}
+}
+
+//测试超时后，超时的协程写入session不会与session的落地并发，写了一半的session不会被保存，需要配合 -race 运行
+func TestTimeoutSession(t *testing.T) {
+	app := New(true)
+	sessionHandler := &testSessionHandler{}
+	app.SetSessionHandler(sessionHandler)
+	app.Use(func(ctx *Ctx, w *Response, r *Request) {
+		r.Session().Set("user", 1)
+		ctx.Next()
+	})
+	handlerDone := make(chan struct{})
+	app.Mux().Get("/slow", func(ctx *Ctx, w *Response, r *Request) error {
+		defer close(handlerDone)
+		<-ctx.Done()
+		for i := 0; i < 100; i++ {
+			r.Session().Set(i, i)
+		}
+		return nil
+	}).Use(Timeout(5 * time.Millisecond))
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	<-handlerDone
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatal("TestTimeoutSession fatal", rec.Code)
+	}
+	if n := atomic.LoadInt32(&sessionHandler.saved); n != 0 {
+		t.Fatal("TestTimeoutSession save fatal", n)
+	}
+}
+
+//测试超时之后的恐慌会交给恐慌恢复函数
+func TestTimeoutPanic(t *testing.T) {
+	app := New(true)
+	recovered := make(chan interface{}, 1)
+	app.SetRecoverFunc(func(ctx *Ctx, a interface{}) {
+		recovered <- a
+	})
+	app.Mux().Get("/panic", func(ctx *Ctx, w *Response, r *Request) error {
+		<-ctx.Done()
+		panic("late panic")
+	}).Use(Timeout(5 * time.Millisecond))
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatal("TestTimeoutPanic fatal", rec.Code)
+	}
+	select {
+	case a := <-recovered:
+		if a != "late panic" {
+			t.Fatal("TestTimeoutPanic recover fatal", a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("TestTimeoutPanic recover timeout")
+	}
+	//处理完毕之前的恐慌在当前协程中重新抛出
+	app.Mux().Get("/panic2", func(ctx *Ctx, w *Response, r *Request) error {
+		panic("early panic")
+	}).Use(Timeout(time.Second))
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic2", nil))
+	if a := <-recovered; a != "early panic" {
+		t.Fatal("TestTimeoutPanic early fatal", a)
+	}
+}
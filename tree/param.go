@@ -0,0 +1,101 @@
+package tree
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+//内置的参数约束类型
+var constraints = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"alpha": `[A-Za-z]+`,
+	"hex":   `[0-9a-fA-F]+`,
+}
+
+//路径中的参数
+type PathParam struct {
+	//参数类型，':'是普通参数，'*'是通配参数
+	Kind rune
+	//参数名称
+	Name string
+	//参数约束，内置类型或者是正则表达式，例如：/user/:id<int>、/:slug<[a-z-]+>
+	Constraint string
+	//参数约束编译后的正则，没有约束时为nil
+	Regexp *regexp.Regexp
+}
+
+//判断参数值是否满足约束
+func (this PathParam) Match(value string) bool {
+	return this.Regexp == nil || this.Regexp.MatchString(value)
+}
+
+//判断是否为参数名称的字符
+func isParamName(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_' || c == '-' || c == '.'
+}
+
+//编译参数约束
+func compileConstraint(constraint string) (*regexp.Regexp, error) {
+	if pattern, ok := constraints[constraint]; ok {
+		constraint = pattern
+	}
+	return regexp.Compile(`^(?:` + constraint + `)$`)
+}
+
+//解析路径，返回参数被替换为参数类型后的路径，以及路径中的参数
+//比如 /user/:id<int>/*path 返回 /user/:/* 与 id、path 两个参数
+func ParsePath(path string) (string, []PathParam, error) {
+	var buf strings.Builder
+	params := make([]PathParam, 0)
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c != ':' && c != '*' {
+			buf.WriteByte(c)
+			continue
+		}
+		//提取参数名称
+		j := i + 1
+		for j < len(path) && isParamName(path[j]) {
+			j++
+		}
+		if j == i+1 {
+			return "", nil, errors.New("path format error, param name not allow empty " + path)
+		}
+		param := PathParam{Kind: rune(c), Name: path[i+1 : j]}
+		//提取参数约束，约束中允许出现成对的尖括号
+		if j < len(path) && path[j] == '<' {
+			depth := 0
+			k := j
+			for ; k < len(path); k++ {
+				if path[k] == '<' {
+					depth++
+				} else if path[k] == '>' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if k == len(path) || k == j+1 {
+				return "", nil, errors.New("path format error, invalid param constraint " + path)
+			}
+			param.Constraint = path[j+1 : k]
+			var err error
+			if param.Regexp, err = compileConstraint(param.Constraint); err != nil {
+				return "", nil, errors.New("path format error, invalid param constraint " + path + " " + err.Error())
+			}
+			j = k + 1
+		}
+		//通配参数只能出现在路径的末尾
+		if c == '*' && j != len(path) {
+			return "", nil, errors.New("path format error, catch-all param must be at the end " + path)
+		}
+		buf.WriteByte(c)
+		params = append(params, param)
+		i = j - 1
+	}
+	return buf.String(), params, nil
+}
@@ -10,22 +10,38 @@ import (
 type Node struct {
 	//父级
 	parent *Node
-	//子节点
-	child map[rune]*Node
-	//节点名称
+	//静态节点的路径片段
+	path string
+	//静态子节点路径片段的首字节，与静态子节点一一对应
+	indices string
+	//静态子节点
+	child []*Node
+	//动态子节点，按匹配的优先级排序：带约束的参数、普通参数、通配参数
+	dynamic []*Node
+	//节点名称，静态节点为0，动态节点为':'或'*'
 	name rune
-	//动态参数名称
+	//动态参数名称，name为':'时是普通参数，name为'*'时是通配参数
+	//不同路径在同一位置上的参数可以有不同的名称，这里只记录第一次添加时的名称
 	paramName string
+	//动态参数约束
+	constraint string
+	//动态参数约束编译后的正则
+	regexp *regexp.Regexp
 	//节点中存储的数据
 	//不能是nil
 	data interface{}
+	//存储了数据的节点对应的路径中的参数名称，按参数出现的顺序排列
+	paramNames []string
 }
 
 //新建一个节点
 func NewNode(parent *Node, name rune) *Node {
 	tmp := &Node{
 		parent:    parent,
-		child:     make(map[rune]*Node),
+		path:      "",
+		indices:   "",
+		child:     nil,
+		dynamic:   nil,
 		name:      name,
 		paramName: "",
 		data:      nil,
@@ -33,6 +49,101 @@ func NewNode(parent *Node, name rune) *Node {
 	return tmp
 }
 
+//判断是否为动态节点的名称
+func isDynamic(name rune) bool {
+	return name == ':' || name == '*'
+}
+
+//动态节点匹配的优先级，值越小越优先
+func (this *Node) priority() int {
+	if this.name == '*' {
+		return 2
+	}
+	if this.regexp == nil {
+		return 1
+	}
+	return 0
+}
+
+//返回两个字符串的公共前缀长度
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+//插入静态路径片段，返回片段末尾的节点
+func (this *Node) addStatic(path string) *Node {
+	currNode := this
+	for path != "" {
+		index := strings.IndexByte(currNode.indices, path[0])
+		if index == -1 {
+			//没有公共前缀，新增子节点
+			node := NewNode(currNode, 0)
+			node.path = path
+			currNode.indices += path[:1]
+			currNode.child = append(currNode.child, node)
+			return node
+		}
+		node := currNode.child[index]
+		l := commonPrefix(path, node.path)
+		if l < len(node.path) {
+			//拆分子节点，公共前缀作为新的子节点
+			prefix := NewNode(currNode, 0)
+			prefix.path = node.path[:l]
+			prefix.indices = node.path[l : l+1]
+			prefix.child = []*Node{node}
+			node.path = node.path[l:]
+			node.parent = prefix
+			currNode.child[index] = prefix
+			node = prefix
+		}
+		currNode = node
+		path = path[l:]
+	}
+	return currNode
+}
+
+//插入动态节点，返回该动态节点，相同类型与约束的参数共用一个节点
+func (this *Node) addDynamic(param PathParam) *Node {
+	if node := this.lookupDynamic(param); node != nil {
+		return node
+	}
+	node := NewNode(this, param.Kind)
+	node.paramName = param.Name
+	node.constraint = param.Constraint
+	node.regexp = param.Regexp
+	//按优先级插入，相同优先级的按插入顺序排列
+	i := len(this.dynamic)
+	for k, v := range this.dynamic {
+		if node.priority() < v.priority() {
+			i = k
+			break
+		}
+	}
+	this.dynamic = append(this.dynamic, nil)
+	copy(this.dynamic[i+1:], this.dynamic[i:])
+	this.dynamic[i] = node
+	return node
+}
+
+//返回当前节点对应的路径
+func (this *Node) String() string {
+	path := ""
+	for currNode := this; currNode != nil; currNode = currNode.parent {
+		if !isDynamic(currNode.name) {
+			path = currNode.path + path
+		} else if currNode.constraint == "" {
+			path = string(currNode.name) + currNode.paramName + path
+		} else {
+			path = string(currNode.name) + currNode.paramName + "<" + currNode.constraint + ">" + path
+		}
+	}
+	return path
+}
+
 //节点树
 type Tree struct {
 	//节点
@@ -59,117 +170,246 @@ func (this *Tree) Add(path string, data interface{}) error {
 	if data == nil {
 		return errors.New("data not allow nil")
 	}
-	var currPath = path
-	//提取参数名
-	re := regexp.MustCompile(`:[0-9A-Za-z_\-\.]+`)
-	param := re.FindAllString(path, -1)
-	if len(param) > 0 {
-		path = re.ReplaceAllString(path, ":")
+	//提取参数
+	path, param, err := ParsePath(path)
+	if err != nil {
+		return err
 	}
-	//判断是否严格大小写
-	if !this.strict {
-		path = strings.ToLower(path)
-	}
-	//开始插入节点
+	//开始插入节点，静态片段与动态参数交替插入
 	var i int = 0
 	var currNode *Node = this.root
-	for _, v := range path {
-		if node, ok := currNode.child[v]; ok {
-			//节点存在继续遍历
-			currNode = node
-			//动态参数索引自增
-			if v == ':' {
-				i++
-			}
-		} else {
-			//检查节点是否冲突
-			//冲突规则：静态节点上插入动态节点或者是动态节点上插入静态节点
-			if _, ok := currNode.child[':']; (v == ':' && len(currNode.child) > 0) || (v != ':' && ok) {
-				for k, _ := range currNode.child {
-					if v == ':' {
-						if k != ':' {
-							currNode = currNode.child[k]
-							break
-						}
-					} else {
-						if k == ':' {
-							currNode = currNode.child[k]
-							break
-						}
-					}
-				}
-				conflict := ""
-				for currNode.parent != nil {
-					if currNode.paramName == "" {
-						conflict = string(currNode.name) + conflict
-					} else {
-						conflict = ":" + currNode.paramName + conflict
-					}
-					currNode = currNode.parent
-				}
-				return errors.New("path conflict " + conflict + " " + currPath)
-			}
-			//新增子节点
-			currNode.child[v] = NewNode(currNode, v)
-			//新增的子节点为动态节点，提取参数名称
-			if v == ':' {
-				//检查参数名称是否正确
-				if i >= len(param) || param[i][1:] == "" {
-					return errors.New("path format error")
-				}
-				currNode.child[v].paramName = param[i][1:]
-				i++
+	for path != "" {
+		index := strings.IndexAny(path, ":*")
+		if index == -1 {
+			index = len(path)
+		}
+		if index > 0 {
+			static := path[:index]
+			//判断是否严格大小写
+			if !this.strict {
+				static = strings.ToLower(static)
 			}
-			//继续向下遍历
-			currNode = currNode.child[v]
+			currNode = currNode.addStatic(static)
+			path = path[index:]
+			continue
 		}
+		currNode = currNode.addDynamic(param[i])
+		i++
+		path = path[1:]
+	}
+	//同一位置已经存储了data，参数名称不同也视为同一条路径
+	if currNode.data != nil {
+		return errors.New("path conflict with " + currNode.String())
 	}
 	//存储data
 	currNode.data = data
+	currNode.paramNames = make([]string, 0, len(param))
+	for _, v := range param {
+		currNode.paramNames = append(currNode.paramNames, v.Name)
+	}
 	//返回成功
 	return nil
 }
 
+//查找与路径完全相同的路由所存储的数据，参数只比较类型与约束，不比较名称
+//比如添加了 /user/:id 之后，查找 /user/:uid 会返回 /user/:id 存储的数据
+func (this *Tree) Lookup(path string) (interface{}, bool) {
+	node := this.lookup(path)
+	if node == nil || node.data == nil {
+		return nil, false
+	}
+	return node.data, true
+}
+
+//查找与路径完全相同的节点
+func (this *Tree) lookup(path string) *Node {
+	path, param, err := ParsePath(path)
+	if err != nil {
+		return nil
+	}
+	var i int = 0
+	var currNode *Node = this.root
+	for path != "" && currNode != nil {
+		index := strings.IndexAny(path, ":*")
+		if index == -1 {
+			index = len(path)
+		}
+		if index > 0 {
+			static := path[:index]
+			if !this.strict {
+				static = strings.ToLower(static)
+			}
+			currNode = currNode.lookupStatic(static)
+			path = path[index:]
+			continue
+		}
+		currNode = currNode.lookupDynamic(param[i])
+		i++
+		path = path[1:]
+	}
+	return currNode
+}
+
+//删除与路径完全相同的路由，返回被删除的数据，参数只比较类型与约束，不比较名称
+//只清除节点中的数据，不回收节点
+func (this *Tree) Remove(path string) (interface{}, bool) {
+	node := this.lookup(path)
+	if node == nil || node.data == nil {
+		return nil, false
+	}
+	data := node.data
+	node.data = nil
+	node.paramNames = nil
+	return data, true
+}
+
+//复制一颗节点树，f用于复制节点中存储的数据
+func (this *Tree) Clone(f func(data interface{}) interface{}) *Tree {
+	return &Tree{
+		root:   this.root.clone(nil, f),
+		strict: this.strict,
+	}
+}
+
+//复制当前节点及其全部子节点
+func (this *Node) clone(parent *Node, f func(data interface{}) interface{}) *Node {
+	tmp := new(Node)
+	*tmp = *this
+	tmp.parent = parent
+	if this.data != nil {
+		tmp.data = f(this.data)
+	}
+	if this.child != nil {
+		tmp.child = make([]*Node, 0, len(this.child))
+		for _, v := range this.child {
+			tmp.child = append(tmp.child, v.clone(tmp, f))
+		}
+	}
+	if this.dynamic != nil {
+		tmp.dynamic = make([]*Node, 0, len(this.dynamic))
+		for _, v := range this.dynamic {
+			tmp.dynamic = append(tmp.dynamic, v.clone(tmp, f))
+		}
+	}
+	return tmp
+}
+
+//查找静态路径片段末尾的节点
+func (this *Node) lookupStatic(path string) *Node {
+	currNode := this
+	for path != "" {
+		index := strings.IndexByte(currNode.indices, path[0])
+		if index == -1 {
+			return nil
+		}
+		node := currNode.child[index]
+		if !strings.HasPrefix(path, node.path) {
+			return nil
+		}
+		currNode = node
+		path = path[len(node.path):]
+	}
+	return currNode
+}
+
+//查找相同类型与约束的动态节点
+func (this *Node) lookupDynamic(param PathParam) *Node {
+	for _, node := range this.dynamic {
+		if node.name == param.Kind && node.constraint == param.Constraint {
+			return node
+		}
+	}
+	return nil
+}
+
 //搜索到的参数的存储接口
 type Store interface {
 	Set(key string, v interface{})
 }
 
+//匹配剩余路径为空的通配节点，比如 /static/*filepath 匹配 /static 或 /static/
+func (this *Node) emptyCatchAll(params []string) (*Node, []string) {
+	for _, node := range this.dynamic {
+		if node.name == '*' && node.data != nil && node.regexp == nil {
+			return node, append(params, "")
+		}
+	}
+	return nil, params
+}
+
+//在当前节点下查找路径，静态节点优先，匹配失败时回溯尝试动态节点
+//path用于匹配节点，raw是与path等长的原始路径，用于校验参数约束与提取参数
+func (this *Node) search(path string, raw string, params []string) (*Node, []string) {
+	if path == "" {
+		if this.data != nil {
+			return this, params
+		}
+		if result, tmp := this.emptyCatchAll(params); result != nil {
+			return result, tmp
+		}
+	} else if index := strings.IndexByte(this.indices, path[0]); index != -1 {
+		//优先匹配静态节点
+		node := this.child[index]
+		if strings.HasPrefix(path, node.path) {
+			if result, tmp := node.search(path[len(node.path):], raw[len(node.path):], params); result != nil {
+				return result, tmp
+			}
+		} else if len(node.path) == len(path)+1 && node.path[len(path)] == '/' && strings.HasPrefix(node.path, path) {
+			//路径比静态节点只少了末尾的斜杠，没有匹配的通配节点则继续尝试动态节点
+			if result, tmp := node.emptyCatchAll(params); result != nil {
+				return result, tmp
+			}
+		}
+	}
+	if path == "" {
+		if index := strings.IndexByte(this.indices, '/'); index != -1 && this.child[index].path == "/" {
+			return this.child[index].emptyCatchAll(params)
+		}
+		return nil, params
+	}
+	//再按优先级匹配动态节点
+	for _, node := range this.dynamic {
+		if node.name == '*' {
+			//通配节点，剩余的路径全部作为参数
+			if node.data != nil && (node.regexp == nil || node.regexp.MatchString(raw)) {
+				return node, append(params, raw)
+			}
+			continue
+		}
+		l := len(path)
+		if index := strings.IndexByte(path, '/'); index != -1 {
+			l = index
+		}
+		value := raw[:l]
+		if node.regexp != nil && !node.regexp.MatchString(value) {
+			continue
+		}
+		if result, tmp := node.search(path[l:], raw[l:], append(params, value)); result != nil {
+			return result, tmp
+		}
+	}
+	return nil, params
+}
+
 //查找当前路径是否在节点树中
+//不区分大小写时，参数的值保持原始路径中的大小写
 func (this *Tree) Search(path string, param Store) (interface{}, bool) {
+	raw := path
 	if !this.strict {
 		path = strings.ToLower(path)
-	}
-	var currNode *Node = this.root
-loop:
-	for k, v := range path {
-		if node, ok := currNode.child[v]; ok {
-			//静态节点，继续遍历
-			currNode = node
-		} else {
-			//判断是否存在动态节点
-			if node, ok := currNode.child[':']; ok {
-				//找到了动态的节点
-				currNode = node
-				//提取参数到当前的请求
-				index := strings.IndexByte(path[k:], '/')
-				if index == -1 {
-					param.Set(node.paramName, path[k:])
-					break
-				} else {
-					param.Set(node.paramName, path[k:k+index])
-					path = path[k+index:]
-					goto loop
-				}
-			} else {
-				//没找到动态节点，直接返回
-				return nil, false
-			}
+		//极少数字符转为小写后长度会发生变化，此时只能使用小写的参数值
+		if len(path) != len(raw) {
+			raw = path
 		}
 	}
-	//当前节点并非挂载数据的节点
-	if currNode.data == nil {
+	var buf [8]string
+	result, params := this.root.search(path, raw, buf[:0])
+	if result == nil {
 		return nil, false
 	}
-	return currNode.data, true
+	//提取参数到当前的请求
+	for k, v := range params {
+		param.Set(result.paramNames[k], v)
+	}
+	return result.data, true
 }
@@ -1,6 +1,7 @@
 package tree
 
 import (
+	"strconv"
 	"testing"
 )
 
@@ -127,3 +128,376 @@ func TestDynamicUrl(t *testing.T) {
 		t.Error("动态参数测试失败")
 	}
 }
+
+//测试通配路由
+func TestCatchAllUrl(t *testing.T) {
+	tree := New(true)
+	var param Param
+
+	if err := tree.Add("/static/*filepath", 1); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/proxy/:host/*rest", 2); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/static/*path", 3); err == nil {
+		t.Fatal("通配路由冲突检测失败")
+	}
+	if err := tree.Add("/proxy/:addr/*rest", 3); err == nil {
+		t.Fatal("参数路由冲突检测失败")
+	}
+	if err := tree.Add("/files/*path/info", 3); err == nil {
+		t.Fatal("通配参数必须在路径末尾")
+	}
+
+	param = make(Param)
+	data, ok := tree.Search("/static/css/app.css", param)
+	if !ok || data.(int) != 1 {
+		t.Fatal("通配路由 /static/*filepath 查找失败")
+	}
+	if tmp := param.Get("filepath"); tmp != "css/app.css" {
+		t.Fatal("通配路由 /static/*filepath 查找失败 " + tmp)
+	}
+
+	param = make(Param)
+	data, ok = tree.Search("/static", param)
+	if !ok || data.(int) != 1 {
+		t.Fatal("通配路由 /static/*filepath 查找失败")
+	}
+	if tmp, ok := param["filepath"]; !ok || tmp != "" {
+		t.Fatal("通配路由 /static/*filepath 查找失败 " + tmp)
+	}
+
+	param = make(Param)
+	data, ok = tree.Search("/proxy/example.com/api/v1/User", param)
+	if !ok || data.(int) != 2 {
+		t.Fatal("通配路由 /proxy/:host/*rest 查找失败")
+	}
+	if param.Get("host") != "example.com" || param.Get("rest") != "api/v1/User" {
+		t.Fatal("通配路由 /proxy/:host/*rest 查找失败")
+	}
+
+	if _, ok = tree.Search("/proxy", make(Param)); ok {
+		t.Fatal("通配路由 /proxy 不应该被匹配")
+	}
+}
+
+//测试带类型约束的参数
+func TestConstraintUrl(t *testing.T) {
+	tree := New(false)
+	var param Param
+
+	if err := tree.Add("/user/:id<int>", 1); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/user/me", 2); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/user/:uuid<uuid>/profile", 3); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/post/:slug<[a-z-]+>", 4); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/post/:hash<hex>/raw", 5); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/user/:id<", 6); err == nil {
+		t.Fatal("参数约束格式检测失败")
+	}
+
+	param = make(Param)
+	data, ok := tree.Search("/user/100", param)
+	if !ok || data.(int) != 1 || param.Get("id") != "100" {
+		t.Fatal("/user/:id<int> 查找失败")
+	}
+
+	param = make(Param)
+	data, ok = tree.Search("/user/me", param)
+	if !ok || data.(int) != 2 || len(param) != 0 {
+		t.Fatal("/user/me 查找失败")
+	}
+
+	param = make(Param)
+	data, ok = tree.Search("/user/0b0a6f5e-3c1d-4f7a-9e2b-6d5c4b3a2f10/profile", param)
+	if !ok || data.(int) != 3 || param.Get("uuid") != "0b0a6f5e-3c1d-4f7a-9e2b-6d5c4b3a2f10" {
+		t.Fatal("/user/:uuid<uuid>/profile 查找失败")
+	}
+
+	if _, ok = tree.Search("/user/abc", make(Param)); ok {
+		t.Fatal("/user/abc 不应该被匹配")
+	}
+
+	//回溯：abc 同时满足 slug 与 hex，但只有 hex 分支存在 /raw
+	param = make(Param)
+	data, ok = tree.Search("/post/abc/raw", param)
+	if !ok || data.(int) != 5 || param.Get("hash") != "abc" {
+		t.Fatal("/post/:hash<hex>/raw 查找失败")
+	}
+	if _, ok := param["slug"]; ok {
+		t.Fatal("回溯后不应该保留失败分支的参数")
+	}
+
+	param = make(Param)
+	data, ok = tree.Search("/post/hello-world", param)
+	if !ok || data.(int) != 4 || param.Get("slug") != "hello-world" {
+		t.Fatal("/post/:slug<[a-z-]+> 查找失败")
+	}
+}
+
+//测试静态节点与动态节点共存
+func TestStaticDynamicCoexist(t *testing.T) {
+	tree := New(false)
+	var param Param
+
+	if err := tree.Add("/users/:id", 1); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/users/new", 2); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/users/:id/edit", 3); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/users/new/:step", 4); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/users/*path", 5); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/users/:uid/profile", 6); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+
+	param = make(Param)
+	data, ok := tree.Search("/users/new", param)
+	if !ok || data.(int) != 2 || len(param) != 0 {
+		t.Fatal("/users/new 查找失败")
+	}
+
+	param = make(Param)
+	data, ok = tree.Search("/users/newer", param)
+	if !ok || data.(int) != 1 || param.Get("id") != "newer" {
+		t.Fatal("/users/:id 查找失败")
+	}
+
+	param = make(Param)
+	data, ok = tree.Search("/users/new/edit", param)
+	if !ok || data.(int) != 4 || param.Get("step") != "edit" {
+		t.Fatal("/users/new/:step 查找失败")
+	}
+
+	//回溯：静态节点 new 与参数节点 :id 都无法匹配，最终由通配节点匹配
+	param = make(Param)
+	data, ok = tree.Search("/users/new/a/b", param)
+	if !ok || data.(int) != 5 || param.Get("path") != "new/a/b" {
+		t.Fatal("/users/*path 查找失败")
+	}
+	if len(param) != 1 {
+		t.Fatal("回溯后不应该保留失败分支的参数")
+	}
+
+	param = make(Param)
+	data, ok = tree.Search("/users/10/edit", param)
+	if !ok || data.(int) != 3 || param.Get("id") != "10" {
+		t.Fatal("/users/:id/edit 查找失败")
+	}
+
+	//同一位置上的参数在不同的路径中可以有不同的名称
+	param = make(Param)
+	data, ok = tree.Search("/users/10/profile", param)
+	if !ok || data.(int) != 6 || param.Get("uid") != "10" {
+		t.Fatal("/users/:uid/profile 查找失败")
+	}
+	if _, ok := param["id"]; ok {
+		t.Fatal("/users/:uid/profile 不应该存在参数 id")
+	}
+
+	param = make(Param)
+	data, ok = tree.Search("/users/10/edit/more", param)
+	if !ok || data.(int) != 5 || param.Get("path") != "10/edit/more" {
+		t.Fatal("/users/*path 查找失败")
+	}
+	if _, ok := param["id"]; ok {
+		t.Fatal("回溯后不应该保留失败分支的参数")
+	}
+
+	//路径比静态节点只少了末尾的斜杠，且静态节点下没有通配节点时，回溯到参数节点
+	tree = New(true)
+	for k, v := range []string{"/static/a", "/static/b", "/:name"} {
+		if err := tree.Add(v, k+1); err != nil {
+			t.Fatal("添加路由失败", err)
+		}
+	}
+	param = make(Param)
+	data, ok = tree.Search("/static", param)
+	if !ok || data.(int) != 3 || param.Get("name") != "static" {
+		t.Fatal("/:name 查找失败")
+	}
+}
+
+//测试大小写
+func TestCaseSensitive(t *testing.T) {
+	var param Param
+
+	tree := New(false)
+	if err := tree.Add("/Files/:hash<[A-Z0-9]+>", 1); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/Download/*path", 2); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	param = make(Param)
+	data, ok := tree.Search("/files/ABC123", param)
+	if !ok || data.(int) != 1 || param.Get("hash") != "ABC123" {
+		t.Fatal("不区分大小写时参数值应该保持原始的大小写")
+	}
+	if _, ok = tree.Search("/files/abc123", make(Param)); ok {
+		t.Fatal("参数约束应该校验原始的参数值")
+	}
+	param = make(Param)
+	data, ok = tree.Search("/DOWNLOAD/Dir/File.TXT", param)
+	if !ok || data.(int) != 2 || param.Get("path") != "Dir/File.TXT" {
+		t.Fatal("不区分大小写时通配参数值应该保持原始的大小写")
+	}
+
+	tree = New(true)
+	if err := tree.Add("/Files/:hash", 1); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if err := tree.Add("/files/:hash", 2); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	param = make(Param)
+	data, ok = tree.Search("/Files/ABC", param)
+	if !ok || data.(int) != 1 || param.Get("hash") != "ABC" {
+		t.Fatal("/Files/:hash 查找失败")
+	}
+	param = make(Param)
+	data, ok = tree.Search("/files/abc", param)
+	if !ok || data.(int) != 2 || param.Get("hash") != "abc" {
+		t.Fatal("/files/:hash 查找失败")
+	}
+	if _, ok = tree.Search("/FILES/abc", make(Param)); ok {
+		t.Fatal("区分大小写时 /FILES/abc 不应该被匹配")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tree := New(false)
+	for k, v := range []string{"/users", "/users/:id", "/users/:id<int>", "/Static/*path"} {
+		if err := tree.Add(v, k+1); err != nil {
+			t.Fatal("添加路由失败", err)
+		}
+	}
+	for path, want := range map[string]int{
+		"/users":           1,
+		"/users/:uid":      2,
+		"/users/:uid<int>": 3,
+		"/static/*fp":      4,
+	} {
+		if data, ok := tree.Lookup(path); !ok || data.(int) != want {
+			t.Fatal(path + " 查找失败")
+		}
+	}
+	for _, path := range []string{"/user", "/users/", "/users/:id<uint>", "/users/*id", "/users/1"} {
+		if _, ok := tree.Lookup(path); ok {
+			t.Fatal(path + " 不应该被找到")
+		}
+	}
+}
+
+func TestRemoveAndClone(t *testing.T) {
+	tree := New(false)
+	for k, v := range []string{"/users", "/users/:id", "/static/*path"} {
+		if err := tree.Add(v, k+1); err != nil {
+			t.Fatal("添加路由失败", err)
+		}
+	}
+	clone := tree.Clone(func(data interface{}) interface{} {
+		return data.(int) * 10
+	})
+	if data, ok := tree.Remove("/users/:uid"); !ok || data.(int) != 2 {
+		t.Fatal("/users/:uid 删除失败")
+	}
+	if _, ok := tree.Remove("/users/:uid"); ok {
+		t.Fatal("重复删除应该失败")
+	}
+	if _, ok := tree.Search("/users/1", make(Param)); ok {
+		t.Fatal("被删除的路由不应该被匹配")
+	}
+	if data, ok := tree.Search("/users", make(Param)); !ok || data.(int) != 1 {
+		t.Fatal("删除路由不应该影响其它路由")
+	}
+	param := make(Param)
+	if data, ok := clone.Search("/users/1", param); !ok || data.(int) != 20 || param.Get("id") != "1" {
+		t.Fatal("修改原节点树不应该影响复制的节点树")
+	}
+	if err := clone.Add("/users/:id/posts", 40); err != nil {
+		t.Fatal("添加路由失败", err)
+	}
+	if _, ok := tree.Search("/users/1/posts", make(Param)); ok {
+		t.Fatal("修改复制的节点树不应该影响原节点树")
+	}
+}
+
+//生成类似后台管理系统的路由，共1500条
+func benchmarkPaths() []string {
+	paths := make([]string, 0, 1500)
+	for i := 0; i < 300; i++ {
+		prefix := "/admin/module" + strconv.Itoa(i)
+		paths = append(paths,
+			prefix+"/index",
+			prefix+"/create",
+			prefix+"/edit/:id",
+			prefix+"/show/:id",
+			prefix+"/delete/:id",
+		)
+	}
+	return paths
+}
+
+func benchmarkTree(b *testing.B) *Tree {
+	tree := New(false)
+	for k, v := range benchmarkPaths() {
+		if err := tree.Add(v, k+1); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return tree
+}
+
+func BenchmarkAdd(b *testing.B) {
+	paths := benchmarkPaths()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tree := New(false)
+		for k, v := range paths {
+			_ = tree.Add(v, k+1)
+		}
+	}
+}
+
+func BenchmarkSearchStatic(b *testing.B) {
+	tree := benchmarkTree(b)
+	param := make(Param)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := tree.Search("/admin/module150/index", param); !ok {
+			b.Fatal("查找失败")
+		}
+	}
+}
+
+func BenchmarkSearchDynamic(b *testing.B) {
+	tree := benchmarkTree(b)
+	param := make(Param)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := tree.Search("/admin/module299/edit/12345", param); !ok {
+			b.Fatal("查找失败")
+		}
+	}
+}
@@ -74,3 +74,14 @@ func (this *TSMap) Del(key string) {
 		}
 	}
 }
+
+//遍历全部条目，f返回false时停止遍历
+func (this *TSMap) Range(f func(key string, v interface{}) bool) {
+	this.l.RLock()
+	defer this.l.RUnlock()
+	for k, v := range this.store {
+		if !f(k, v) {
+			break
+		}
+	}
+}
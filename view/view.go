@@ -265,17 +265,28 @@ func (this *View) parseTemplateFromCache(tpl string) (*template.Template, error)
 	return t, err
 }
 
-//渲染模板
-func (this *View) Render(wr io.Writer, tpl string, data interface{}) error {
+//渲染模板，funcMap用于替换已经注册的同名模板函数，只对本次渲染生效
+func (this *View) Render(wr io.Writer, tpl string, data interface{}, funcMap ...template.FuncMap) error {
 	var t *template.Template
 	var err error
 	if this.isCache {
 		t, err = this.parseTemplateFromCache(tpl)
+		if err == nil && len(funcMap) > 0 {
+			//缓存的模板是共享的，复制后再替换模板函数，已经执行过的模板无法复制，只能重新解析
+			if tmp, cloneErr := t.Clone(); cloneErr == nil {
+				t = tmp
+			} else {
+				t, err = this.parseTemplate(tpl)
+			}
+		}
 	} else {
 		t, err = this.parseTemplate(tpl)
 	}
 	if err != nil {
 		return fmt.Errorf("view render error: %w", err)
 	}
+	for _, v := range funcMap {
+		t.Funcs(v)
+	}
 	return t.Execute(wr, data)
 }
@@ -0,0 +1,365 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+//消息超过大小限制
+var ErrReadLimit = errors.New("websocket: read limit exceeded")
+
+//连接已经关闭
+var ErrCloseSent = errors.New("websocket: close sent")
+
+//对端发送了关闭帧，或者是因为协议错误而关闭了连接
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (this *CloseError) Error() string {
+	return "websocket: close " + strconv.Itoa(this.Code) + " " + this.Text
+}
+
+//判断是否是对端发送的、指定关闭码的关闭错误
+func IsCloseError(err error, codes ...int) bool {
+	if e, ok := err.(*CloseError); ok {
+		for _, code := range codes {
+			if e.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//控制帧的最大长度
+const maxControlFramePayloadSize = 125
+
+//WebSocket连接
+//读取只能在一个协程中进行，写入可以在多个协程中进行
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	subprotocol string
+	readLimit   int64
+	pongHandler func(data []byte)
+	//写锁
+	wl sync.Mutex
+	bw *bufio.Writer
+	//是否已经发送了关闭帧
+	closeSent bool
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, subprotocol string, readLimit int64) *Conn {
+	return &Conn{
+		conn:        conn,
+		br:          br,
+		subprotocol: subprotocol,
+		readLimit:   readLimit,
+		bw:          bufio.NewWriter(conn),
+	}
+}
+
+//返回协商的子协议
+func (this *Conn) Subprotocol() string {
+	return this.subprotocol
+}
+
+//返回底层的连接
+func (this *Conn) NetConn() net.Conn {
+	return this.conn
+}
+
+func (this *Conn) RemoteAddr() net.Addr {
+	return this.conn.RemoteAddr()
+}
+
+func (this *Conn) LocalAddr() net.Addr {
+	return this.conn.LocalAddr()
+}
+
+//设置单个消息的大小限制
+func (this *Conn) SetReadLimit(limit int64) {
+	this.readLimit = limit
+}
+
+func (this *Conn) SetReadDeadline(t time.Time) error {
+	return this.conn.SetReadDeadline(t)
+}
+
+func (this *Conn) SetWriteDeadline(t time.Time) error {
+	return this.conn.SetWriteDeadline(t)
+}
+
+//设置收到pong帧时的处理函数，一般用于配合 Ping 检测连接是否存活
+func (this *Conn) SetPongHandler(h func(data []byte)) {
+	this.pongHandler = h
+}
+
+//帧头
+type frameHeader struct {
+	fin    bool
+	opcode int
+	length int64
+	mask   [4]byte
+}
+
+//读取一个完整的消息，期间收到的ping帧会自动回复pong帧，收到关闭帧会回复关闭帧并返回 *CloseError
+func (this *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	for {
+		h, err := this.readFrameHeader()
+		if err != nil {
+			return 0, nil, err
+		}
+		if h.opcode >= CloseMessage {
+			payload, err := this.readPayload(h)
+			if err != nil {
+				return 0, nil, err
+			}
+			if err := this.handleControl(h.opcode, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+		if h.opcode == 0 {
+			if messageType == 0 {
+				return 0, nil, this.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		} else {
+			if messageType != 0 {
+				return 0, nil, this.fail(CloseProtocolError, "expected continuation frame")
+			}
+			messageType = h.opcode
+		}
+		if this.readLimit > 0 && h.length > this.readLimit-int64(len(p)) {
+			_ = this.fail(CloseMessageTooBig, "message too big")
+			return 0, nil, ErrReadLimit
+		}
+		payload, err := this.readPayload(h)
+		if err != nil {
+			return 0, nil, err
+		}
+		p = append(p, payload...)
+		if h.fin {
+			if messageType == TextMessage && !utf8.Valid(p) {
+				return 0, nil, this.fail(CloseInvalidFramePayloadData, "invalid utf8 payload")
+			}
+			return messageType, p, nil
+		}
+	}
+}
+
+//读取帧头，并校验是否符合协议
+func (this *Conn) readFrameHeader() (frameHeader, error) {
+	var h frameHeader
+	var b [8]byte
+	if _, err := io.ReadFull(this.br, b[:2]); err != nil {
+		return h, err
+	}
+	h.fin = b[0]&0x80 != 0
+	h.opcode = int(b[0] & 0x0f)
+	if b[0]&0x70 != 0 {
+		return h, this.fail(CloseProtocolError, "unexpected reserved bits")
+	}
+	switch h.opcode {
+	case 0, TextMessage, BinaryMessage:
+	case CloseMessage, PingMessage, PongMessage:
+		if !h.fin {
+			return h, this.fail(CloseProtocolError, "fragmented control frame")
+		}
+	default:
+		return h, this.fail(CloseProtocolError, "unknown opcode "+strconv.Itoa(h.opcode))
+	}
+	//客户端发送的帧必须有掩码
+	if b[1]&0x80 == 0 {
+		return h, this.fail(CloseProtocolError, "unmasked client frame")
+	}
+	h.length = int64(b[1] & 0x7f)
+	switch h.length {
+	case 126:
+		if _, err := io.ReadFull(this.br, b[:2]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(this.br, b[:8]); err != nil {
+			return h, err
+		}
+		length := binary.BigEndian.Uint64(b[:8])
+		if length>>63 != 0 {
+			return h, this.fail(CloseProtocolError, "invalid payload length")
+		}
+		h.length = int64(length)
+	}
+	if h.opcode >= CloseMessage && h.length > maxControlFramePayloadSize {
+		return h, this.fail(CloseProtocolError, "control frame too long")
+	}
+	if _, err := io.ReadFull(this.br, h.mask[:]); err != nil {
+		return h, err
+	}
+	return h, nil
+}
+
+//读取并解码帧的内容
+func (this *Conn) readPayload(h frameHeader) ([]byte, error) {
+	payload := make([]byte, h.length)
+	if _, err := io.ReadFull(this.br, payload); err != nil {
+		return nil, err
+	}
+	for i := range payload {
+		payload[i] ^= h.mask[i%4]
+	}
+	return payload, nil
+}
+
+//处理控制帧
+func (this *Conn) handleControl(opcode int, payload []byte) error {
+	switch opcode {
+	case PingMessage:
+		if err := this.WriteControl(PongMessage, payload); err != nil && err != ErrCloseSent {
+			return err
+		}
+	case PongMessage:
+		if this.pongHandler != nil {
+			this.pongHandler(payload)
+		}
+	case CloseMessage:
+		closeErr := &CloseError{Code: CloseNoStatusReceived}
+		if len(payload) == 1 {
+			return this.fail(CloseProtocolError, "invalid close payload")
+		}
+		if len(payload) >= 2 {
+			closeErr.Code = int(binary.BigEndian.Uint16(payload))
+			closeErr.Text = string(payload[2:])
+			if !validCloseCode(closeErr.Code) {
+				return this.fail(CloseProtocolError, "invalid close code")
+			}
+			if !utf8.Valid(payload[2:]) {
+				return this.fail(CloseInvalidFramePayloadData, "invalid utf8 payload")
+			}
+		}
+		//回复关闭帧
+		var reply []byte
+		if closeErr.Code != CloseNoStatusReceived {
+			reply = formatClose(closeErr.Code, "")
+		}
+		_ = this.WriteControl(CloseMessage, reply)
+		return closeErr
+	}
+	return nil
+}
+
+//因为协议错误而关闭连接
+func (this *Conn) fail(code int, text string) error {
+	_ = this.WriteControl(CloseMessage, formatClose(code, text))
+	return &CloseError{Code: code, Text: text}
+}
+
+//发送一个消息
+func (this *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return errors.New("websocket: invalid message type " + strconv.Itoa(messageType))
+	}
+	return this.writeFrame(messageType, data)
+}
+
+//发送一个文本消息
+func (this *Conn) WriteText(text string) error {
+	return this.writeFrame(TextMessage, []byte(text))
+}
+
+//发送一个控制帧，内容不能超过125字节
+func (this *Conn) WriteControl(messageType int, data []byte) error {
+	if messageType != CloseMessage && messageType != PingMessage && messageType != PongMessage {
+		return errors.New("websocket: invalid control message type " + strconv.Itoa(messageType))
+	}
+	if len(data) > maxControlFramePayloadSize {
+		return errors.New("websocket: control frame too long")
+	}
+	return this.writeFrame(messageType, data)
+}
+
+//发送一个ping帧
+func (this *Conn) Ping(data []byte) error {
+	return this.WriteControl(PingMessage, data)
+}
+
+//写入一个帧，服务端发送的帧不需要掩码
+func (this *Conn) writeFrame(opcode int, data []byte) error {
+	this.wl.Lock()
+	defer this.wl.Unlock()
+	if this.closeSent {
+		return ErrCloseSent
+	}
+	if opcode == CloseMessage {
+		this.closeSent = true
+	}
+	var b [10]byte
+	b[0] = 0x80 | byte(opcode)
+	n := 2
+	switch l := len(data); {
+	case l <= 125:
+		b[1] = byte(l)
+	case l <= 0xffff:
+		b[1] = 126
+		binary.BigEndian.PutUint16(b[2:], uint16(l))
+		n = 4
+	default:
+		b[1] = 127
+		binary.BigEndian.PutUint64(b[2:], uint64(l))
+		n = 10
+	}
+	if _, err := this.bw.Write(b[:n]); err != nil {
+		return err
+	}
+	if _, err := this.bw.Write(data); err != nil {
+		return err
+	}
+	return this.bw.Flush()
+}
+
+//发送关闭帧并关闭底层的连接
+func (this *Conn) Close(code int, text string) error {
+	var payload []byte
+	if code != CloseNoStatusReceived {
+		payload = formatClose(code, text)
+	}
+	err := this.WriteControl(CloseMessage, payload)
+	if err == ErrCloseSent {
+		err = nil
+	}
+	if e := this.conn.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+//生成关闭帧的内容，过长的原因会被截断
+func formatClose(code int, text string) []byte {
+	if len(text) > maxControlFramePayloadSize-2 {
+		text = text[:maxControlFramePayloadSize-2]
+	}
+	payload := make([]byte, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], text)
+	return payload
+}
+
+//判断对端发送的关闭码是否合法
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+//RFC 6455 WebSocket的服务端实现
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//消息类型
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+//关闭码
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseInternalServerErr       = 1011
+)
+
+//默认的消息大小限制
+const DefaultReadLimit = 1 << 20
+
+//握手时使用的GUID
+const handshakeGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+//握手错误，一般是客户端的请求不合法
+type HandshakeError struct {
+	message string
+}
+
+func (this HandshakeError) Error() string {
+	return "websocket: " + this.message
+}
+
+//升级器
+type Upgrader struct {
+	//单个消息的大小限制，为0则是 DefaultReadLimit
+	ReadLimit int64
+	//握手的超时时间，为0则不限制
+	HandshakeTimeout time.Duration
+	//支持的子协议，按优先级排列
+	Subprotocols []string
+	//校验请求的来源，为nil则要求Origin与Host一致
+	CheckOrigin func(r *http.Request) bool
+}
+
+//默认的升级器
+var DefaultUpgrader = &Upgrader{}
+
+//将http请求升级为WebSocket连接，header是额外的响应头，w的header也会一并写入
+//握手失败时返回 HandshakeError，不会向w写入任何内容，由调用者负责响应
+func (this *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, header http.Header) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return nil, HandshakeError{"request method is not GET"}
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") {
+		return nil, HandshakeError{"'upgrade' token not found in 'Connection' header"}
+	}
+	if !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, HandshakeError{"'websocket' token not found in 'Upgrade' header"}
+	}
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		return nil, HandshakeError{"unsupported version"}
+	}
+	key := r.Header.Get("Sec-Websocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return nil, HandshakeError{"'Sec-WebSocket-Key' header is invalid"}
+	}
+	checkOrigin := this.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = checkSameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, HandshakeError{"request origin not allowed"}
+	}
+	h, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("websocket: http.Hijacker not supported by %T", w)
+	}
+	subprotocol := this.selectSubprotocol(r)
+	netConn, brw, err := h.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	//劫持之后再读取w的header，劫持时可能会写入header，比如保存session时的cookie
+	respHeader := http.Header{}
+	for k, v := range w.Header() {
+		respHeader[k] = v
+	}
+	for k, v := range header {
+		respHeader[k] = v
+	}
+	if this.HandshakeTimeout > 0 {
+		_ = netConn.SetWriteDeadline(time.Now().Add(this.HandshakeTimeout))
+	}
+	buf := brw.Writer
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	buf.WriteString(acceptKey(key))
+	buf.WriteString("\r\n")
+	if subprotocol != "" {
+		buf.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	for k, vs := range respHeader {
+		switch http.CanonicalHeaderKey(k) {
+		case "Upgrade", "Connection", "Sec-Websocket-Accept", "Sec-Websocket-Protocol", "Content-Type", "Content-Length":
+			continue
+		}
+		for _, v := range vs {
+			buf.WriteString(k + ": " + strings.NewReplacer("\r", "", "\n", "").Replace(v) + "\r\n")
+		}
+	}
+	buf.WriteString("\r\n")
+	if err := buf.Flush(); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	if this.HandshakeTimeout > 0 {
+		_ = netConn.SetWriteDeadline(time.Time{})
+	}
+	readLimit := this.ReadLimit
+	if readLimit <= 0 {
+		readLimit = DefaultReadLimit
+	}
+	return newConn(netConn, brw.Reader, subprotocol, readLimit), nil
+}
+
+//使用默认的升级器升级
+func Upgrade(w http.ResponseWriter, r *http.Request, header http.Header) (*Conn, error) {
+	return DefaultUpgrader.Upgrade(w, r, header)
+}
+
+//判断是否是WebSocket的升级请求
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+func (this *Upgrader) selectSubprotocol(r *http.Request) string {
+	if len(this.Subprotocols) == 0 {
+		return ""
+	}
+	var client []string
+	for _, v := range r.Header["Sec-Websocket-Protocol"] {
+		for _, p := range strings.Split(v, ",") {
+			client = append(client, strings.TrimSpace(p))
+		}
+	}
+	for _, s := range this.Subprotocols {
+		for _, c := range client {
+			if s == c {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+//计算 Sec-WebSocket-Accept
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + handshakeGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+//判断header中是否包含某个token，不区分大小写
+func headerContains(header http.Header, name string, token string) bool {
+	for _, v := range header[http.CanonicalHeaderKey(name)] {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//没有Origin的请求不是来自浏览器，直接允许，否则要求Origin与Host一致
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,125 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//测试握手时 Sec-WebSocket-Accept 的计算，例子取自RFC 6455
+func TestAcceptKey(t *testing.T) {
+	if acceptKey("dGhlIHNhbXBsZSBub25jZQ==") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatal("TestAcceptKey fatal")
+	}
+}
+
+//测试不合法的握手
+func TestHandshakeError(t *testing.T) {
+	var upgradeErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, upgradeErr = Upgrade(w, r, nil)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if _, ok := upgradeErr.(HandshakeError); !ok || res.StatusCode != http.StatusBadRequest {
+		t.Fatal("TestHandshakeError fatal", upgradeErr)
+	}
+}
+
+//测试握手、分片的消息、ping/pong、消息大小限制以及关闭
+func TestEcho(t *testing.T) {
+	upgrader := &Upgrader{ReadLimit: 16}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close(CloseNormalClosure, "")
+		for {
+			messageType, p, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, p); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	_, _ = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: "+strings.TrimPrefix(srv.URL, "http://")+"\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	res, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols || res.Header.Get("Sec-Websocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatal("TestEcho handshake fatal", res.Status)
+	}
+
+	//分片的文本消息，中间夹杂一个ping帧
+	writeClientFrame(conn, false, TextMessage, []byte("hello "))
+	writeClientFrame(conn, true, PingMessage, []byte("p"))
+	writeClientFrame(conn, true, 0, []byte("world"))
+	if opcode, p := readServerFrame(t, br); opcode != PongMessage || string(p) != "p" {
+		t.Fatal("TestEcho pong fatal", opcode, string(p))
+	}
+	if opcode, p := readServerFrame(t, br); opcode != TextMessage || string(p) != "hello world" {
+		t.Fatal("TestEcho message fatal", opcode, string(p))
+	}
+
+	//超过大小限制
+	writeClientFrame(conn, true, BinaryMessage, bytes.Repeat([]byte("a"), 17))
+	opcode, p := readServerFrame(t, br)
+	if opcode != CloseMessage || binary.BigEndian.Uint16(p) != CloseMessageTooBig {
+		t.Fatal("TestEcho read limit fatal", opcode, string(p))
+	}
+}
+
+//客户端发送的帧需要掩码
+func writeClientFrame(w io.Writer, fin bool, opcode int, payload []byte) {
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	mask := [4]byte{1, 2, 3, 4}
+	frame := []byte{b0, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i, v := range payload {
+		frame = append(frame, v^mask[i%4])
+	}
+	_, _ = w.Write(frame)
+}
+
+func readServerFrame(t *testing.T, r io.Reader) (int, []byte) {
+	var b [2]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		t.Fatal(err)
+	}
+	if b[1]&0x80 != 0 || b[1]&0x7f > 125 {
+		t.Fatal("unexpected server frame")
+	}
+	p := make([]byte, b[1]&0x7f)
+	if _, err := io.ReadFull(r, p); err != nil {
+		t.Fatal(err)
+	}
+	return int(b[0] & 0x0f), p
+}